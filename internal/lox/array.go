@@ -1,6 +1,13 @@
 package lox
 
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
 type array struct {
+	mu    sync.RWMutex
 	value []any
 }
 
@@ -9,17 +16,94 @@ func newArray() *array {
 }
 
 func (a *array) Assign(idx int, val any) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	a.value[idx] = val
 }
 
 func (a *array) Get(idx int) any {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	return a.value[idx]
 }
 
 func (a *array) Append(vals ...any) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	a.value = append(a.value, vals...)
 }
 
+// Items returns a copy of the items, which is safe to use while the array is
+// changed, for example by a callback.
+func (a *array) Items() []any {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return append([]any{}, a.value...)
+}
+
+// Pop removes and returns the last item.
+func (a *array) Pop() (any, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.value) == 0 {
+		return nil, builtinErrMsg("Can't pop from an empty array.")
+	}
+	last := a.value[len(a.value)-1]
+	a.value = a.value[:len(a.value)-1]
+	return last, nil
+}
+
+// Insert adds val at idx, shifting later items up. idx may be equal to the
+// length of the array to add val at the end.
+func (a *array) Insert(idx int, val any) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if idx < 0 || idx > len(a.value) {
+		return builtinErrMsg(fmt.Sprintf("Index out of range [%d] with length %d.", idx, len(a.value)))
+	}
+	a.value = slices.Insert(a.value, idx, val)
+	return nil
+}
+
+// Remove removes and returns the item at idx. Negative indexes count from the
+// end of the array.
+func (a *array) Remove(idx int) (any, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	pos := idx
+	if pos < 0 {
+		pos += len(a.value)
+	}
+	if pos < 0 || pos >= len(a.value) {
+		return nil, builtinErrMsg(fmt.Sprintf("Index out of range [%d] with length %d.", idx, len(a.value)))
+	}
+	val := a.value[pos]
+	a.value = slices.Delete(a.value, pos, pos+1)
+	return val, nil
+}
+
+// Shuffle reorders the items with shuffle, which has the signature of
+// rand.Shuffle.
+func (a *array) Shuffle(shuffle func(n int, swap func(i, j int))) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	shuffle(len(a.value), func(i, j int) {
+		a.value[i], a.value[j] = a.value[j], a.value[i]
+	})
+}
+
 func (a *array) Len() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	return len(a.value)
 }
+
+func (a *array) String() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return fmt.Sprint(a.value)
+}
+
+func (a *array) iterator(i *Interpreter) iterator {
+	return &arrayIterator{array: a}
+}
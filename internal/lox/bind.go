@@ -0,0 +1,475 @@
+package lox
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	valueType = reflect.TypeFor[Value]()
+	errorType = reflect.TypeFor[error]()
+)
+
+// goObject exposes a pointer to a Go struct to Lox. Its exported fields can be
+// read and assigned, and its methods called, with the dot operator. A field
+// tagged `lox:"name"` is accessed by that name instead of its Go name.
+type goObject struct {
+	v reflect.Value
+}
+
+// field returns the field of the struct accessed by name. Fields tagged
+// `lox:"-"` are hidden.
+func (o *goObject) field(name string) (reflect.Value, bool) {
+	elem := o.v.Elem()
+	for _, f := range reflect.VisibleFields(elem.Type()) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		fieldName := f.Name
+		if tag := f.Tag.Get("lox"); tag != "" {
+			fieldName = tag
+		}
+		if fieldName != name || fieldName == "-" {
+			continue
+		}
+		// Fields promoted through unexported or nil embedded structs can't be
+		// accessed.
+		v, err := elem.FieldByIndexErr(f.Index)
+		if err != nil || !v.CanSet() {
+			continue
+		}
+		return v, true
+	}
+	return reflect.Value{}, false
+}
+
+func (o *goObject) get(name token) (any, error) {
+	if f, ok := o.field(name.lexeme); ok {
+		val, err := toLox(f)
+		if err != nil {
+			return nil, NewRuntimeError(name, err.Error())
+		}
+		return val, nil
+	}
+	if m := o.v.MethodByName(name.lexeme); m.IsValid() {
+		return bindFunc(name.lexeme, m)
+	}
+	return nil, NewRuntimeError(name, fmt.Sprintf("Undefined properties '%s'", name.lexeme))
+}
+
+func (o *goObject) set(name token, val any) error {
+	f, ok := o.field(name.lexeme)
+	if !ok {
+		return NewRuntimeError(name, fmt.Sprintf("Undefined field '%s'.", name.lexeme))
+	}
+	v, err := fromLox(val, f.Type())
+	if err != nil {
+		return NewRuntimeError(name, fmt.Sprintf("Can't assign to field '%s': %v", name.lexeme, err))
+	}
+	f.Set(v)
+	return nil
+}
+
+func (o *goObject) String() string {
+	if s, ok := o.v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("<go %s>", o.v.Type().Elem())
+}
+
+// typeName describes the type of a Lox value in conversion errors.
+func typeName(val any) string {
+	if obj, ok := val.(*goObject); ok {
+		return obj.v.Type().String()
+	}
+	return Value{val}.Kind().String()
+}
+
+// toLox converts a Go value to a Lox value. Numbers become ints or floats,
+// slices and arrays become arrays, maps become maps with their keys in sorted
+// order, functions become native functions, and structs become objects. Struct
+// values are copied, so only changes through pointers are seen by Go.
+func toLox(v reflect.Value) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.Type() == valueType {
+		return v.Interface().(Value).v, nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt {
+			return nil, fmt.Errorf("%d overflows int", v.Uint())
+		}
+		return int(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		arr := newArray()
+		for idx := range v.Len() {
+			item, err := toLox(v.Index(idx))
+			if err != nil {
+				return nil, err
+			}
+			arr.Append(item)
+		}
+		return arr, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+		})
+		m := newMap()
+		for _, key := range keys {
+			k, err := toLox(key)
+			if err != nil {
+				return nil, err
+			}
+			val, err := toLox(v.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			if err := m.Set(k, val); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case reflect.Func:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return bindFunc(v.Type().String(), v)
+	case reflect.Struct:
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		return &goObject{v: ptr}, nil
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.Elem().Kind() == reflect.Struct {
+			return &goObject{v: v}, nil
+		}
+		return toLox(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return toLox(v.Elem())
+	}
+	return nil, fmt.Errorf("can't convert Go %s to a Lox value", v.Type())
+}
+
+// fromLox converts a Lox value to a Go value of type t. Values converted to
+// interface types keep their natural Go type: arrays become []any, and maps
+// become map[string]any if all their keys are strings and map[any]any
+// otherwise.
+func fromLox(val any, t reflect.Type) (reflect.Value, error) {
+	if t == valueType {
+		return reflect.ValueOf(Value{val}), nil
+	}
+	mismatch := fmt.Errorf("expected %s but got %s", t, typeName(val))
+	if val == nil {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface, reflect.Func:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, mismatch
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, ok := val.(bool); ok {
+			return reflect.ValueOf(b).Convert(t), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := val.(int); ok {
+			out := reflect.New(t).Elem()
+			if out.OverflowInt(int64(n)) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", n, t)
+			}
+			out.SetInt(int64(n))
+			return out, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n, ok := val.(int); ok {
+			out := reflect.New(t).Elem()
+			if n < 0 || out.OverflowUint(uint64(n)) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", n, t)
+			}
+			out.SetUint(uint64(n))
+			return out, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, ok := (Value{val}).AsFloat(); ok {
+			return reflect.ValueOf(f).Convert(t), nil
+		}
+	case reflect.String:
+		if s, ok := val.(string); ok {
+			return reflect.ValueOf(s).Convert(t), nil
+		}
+	case reflect.Slice:
+		if arr, ok := val.(*array); ok {
+			items := arr.Items()
+			out := reflect.MakeSlice(t, len(items), len(items))
+			for idx, item := range items {
+				v, err := fromLox(item, t.Elem())
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("item %d: %w", idx, err)
+				}
+				out.Index(idx).Set(v)
+			}
+			return out, nil
+		}
+	case reflect.Map:
+		if m, ok := val.(*loxMap); ok {
+			out := reflect.MakeMapWithSize(t, m.Len())
+			for _, key := range m.Keys() {
+				k, err := fromLox(key, t.Key())
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("key %v: %w", key, err)
+				}
+				item, _, _ := m.Get(key)
+				v, err := fromLox(item, t.Elem())
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("value of %v: %w", key, err)
+				}
+				out.SetMapIndex(k, v)
+			}
+			return out, nil
+		}
+	case reflect.Pointer:
+		if obj, ok := val.(*goObject); ok && obj.v.Type() == t {
+			return obj.v, nil
+		}
+	case reflect.Struct:
+		if obj, ok := val.(*goObject); ok && obj.v.Type().Elem() == t {
+			return obj.v.Elem(), nil
+		}
+	case reflect.Interface:
+		natural, err := fromLox(val, naturalType(val))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if !natural.Type().AssignableTo(t) {
+			return reflect.Value{}, mismatch
+		}
+		out := reflect.New(t).Elem()
+		out.Set(natural)
+		return out, nil
+	}
+	return reflect.Value{}, mismatch
+}
+
+// naturalType returns the Go type that a Lox value converts to when any type
+// is accepted. Lox values without a Go equivalent, such as functions and
+// instances, are kept as Values.
+func naturalType(val any) reflect.Type {
+	switch v := val.(type) {
+	case bool, int, float64, string:
+		return reflect.TypeOf(v)
+	case *array:
+		return reflect.TypeFor[[]any]()
+	case *loxMap:
+		for _, key := range v.Keys() {
+			if _, ok := key.(string); !ok {
+				return reflect.TypeFor[map[any]any]()
+			}
+		}
+		return reflect.TypeFor[map[string]any]()
+	case *goObject:
+		return v.v.Type()
+	}
+	return valueType
+}
+
+// bindFunc returns a native function that calls fn, converting its arguments
+// from Lox values and its result to a Lox value. fn may return nothing, a
+// value, an error, or a value and an error. A panic in fn becomes a runtime
+// error.
+func bindFunc(name string, fn reflect.Value) (builtinFn, error) {
+	t := fn.Type()
+	numOut := t.NumOut()
+	if numOut > 2 || numOut == 2 && t.Out(1) != errorType {
+		return builtinFn{}, fmt.Errorf("can't bind Go function '%s' of type %s: it must return at most a value and an error", name, t)
+	}
+	returnsErr := numOut > 0 && t.Out(numOut-1) == errorType
+
+	arity := t.NumIn()
+	if t.IsVariadic() {
+		arity = anyArity
+	}
+	return newBuiltinFn(name, arity, func(i *Interpreter, args []any) (res any, err error) {
+		if t.IsVariadic() && len(args) < t.NumIn()-1 {
+			return nil, builtinErrMsg(fmt.Sprintf("Expected at least %d arguments but got %d.", t.NumIn()-1, len(args)))
+		}
+		in := make([]reflect.Value, len(args))
+		for idx, arg := range args {
+			argType := t.In(min(idx, t.NumIn()-1))
+			if t.IsVariadic() && idx >= t.NumIn()-1 {
+				argType = argType.Elem()
+			}
+			if in[idx], err = fromLox(arg, argType); err != nil {
+				return nil, builtinErrMsg(fmt.Sprintf("Argument %d passed to '%s': %v.", idx+1, name, err))
+			}
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				res, err = nil, builtinErrMsg(fmt.Sprintf("Go function '%s' panicked: %v.", name, r))
+			}
+		}()
+		defer i.goCalls.exit(i.goCalls.enter(i))
+		out := fn.Call(in)
+		if returnsErr {
+			if callErr, _ := out[numOut-1].Interface().(error); callErr != nil {
+				return nil, callErr
+			}
+			out = out[:numOut-1]
+		}
+		if len(out) == 0 {
+			return nil, nil
+		}
+		if res, err = toLox(out[0]); err != nil {
+			return nil, builtinErrMsg(fmt.Sprintf("Result of '%s': %v.", name, err))
+		}
+		return res, nil
+	}), nil
+}
+
+// ValueOf converts a Go value to a Lox value. See (*VM).Register for the
+// conversions.
+func ValueOf(x any) (Value, error) {
+	v, err := toLox(reflect.ValueOf(x))
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{v}, nil
+}
+
+// Interface returns the value as a Go value: nil, bool, int, float64, string,
+// []any for arrays, map[string]any for maps whose keys are all strings and
+// map[any]any for other maps, the pointer to the struct of a Go object, or the
+// Value itself for other Lox values.
+func (v Value) Interface() any {
+	if v.v == nil {
+		return nil
+	}
+	out, err := fromLox(v.v, naturalType(v.v))
+	if err != nil {
+		return v
+	}
+	return out.Interface()
+}
+
+// RegisterFunc defines a global native function that is called with its
+// arguments as Values.
+func (vm *VM) RegisterFunc(name string, fn func(args ...Value) (Value, error)) {
+	vm.i.globals.define(name, newBuiltinFn(name, anyArity, func(i *Interpreter, args []any) (any, error) {
+		in := make([]Value, len(args))
+		for idx, arg := range args {
+			in[idx] = Value{arg}
+		}
+		defer i.goCalls.exit(i.goCalls.enter(i))
+		out, err := fn(in...)
+		if err != nil {
+			return nil, err
+		}
+		return out.v, nil
+	}))
+}
+
+// Register defines a global from a Go value. Functions are bound with
+// reflection: their arguments are converted from Lox values and their result
+// to a Lox value, and they may return an error as their last result. Pointers
+// to structs become objects, whose exported fields can be read and assigned
+// and whose methods can be called from Lox. Numbers, strings, booleans, slices
+// and maps are converted to their Lox equivalents.
+func (vm *VM) Register(name string, x any) error {
+	if v := reflect.ValueOf(x); v.Kind() == reflect.Func && !v.IsNil() {
+		// Name the function after the global.
+		fn, err := bindFunc(name, v)
+		if err != nil {
+			return err
+		}
+		vm.SetGlobal(name, Value{fn})
+		return nil
+	}
+	val, err := ValueOf(x)
+	if err != nil {
+		return err
+	}
+	vm.SetGlobal(name, val)
+	return nil
+}
+
+// goCallers records the goroutines that are running Go functions bound by a
+// VM, with the interpreters that called them. Func handles called on one of
+// them run within the script, which is waiting for the Go function to return.
+type goCallers struct {
+	mu      sync.Mutex
+	callers map[uint64][]*Interpreter
+}
+
+func newGoCallers() *goCallers {
+	return &goCallers{callers: make(map[uint64][]*Interpreter)}
+}
+
+// enter records that i is calling a Go function on the current goroutine, and
+// returns the goroutine's id for exit.
+func (g *goCallers) enter(i *Interpreter) uint64 {
+	id := goroutineID()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.callers[id] = append(g.callers[id], i)
+	return id
+}
+
+func (g *goCallers) exit(id uint64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if callers := g.callers[id]; len(callers) > 1 {
+		g.callers[id] = callers[:len(callers)-1]
+	} else {
+		delete(g.callers, id)
+	}
+}
+
+// caller returns the interpreter that called the innermost Go function
+// running on the goroutine id, or nil if there is none.
+func (g *goCallers) caller(id uint64) *Interpreter {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if callers := g.callers[id]; len(callers) > 0 {
+		return callers[len(callers)-1]
+	}
+	return nil
+}
+
+// goroutineID returns the id of the current goroutine, which is the number in
+// the first line of its stack trace, "goroutine 1 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	stack := buf[len("goroutine "):runtime.Stack(buf[:], false)]
+	n, _ := strconv.ParseUint(string(stack[:bytes.IndexByte(stack, ' ')]), 10, 64)
+	return n
+}
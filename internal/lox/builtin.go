@@ -1,6 +1,7 @@
 package lox
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -22,30 +23,68 @@ func (f builtinFn) String() string {
 	return f.stringFn()
 }
 
+// anyArity is the arity of native functions that accept any number of
+// arguments, including none.
+const anyArity = -2
+
+// newBuiltinFn returns a native function with a fixed arity. An arity of -1
+// accepts any number of arguments, with at least one, and anyArity accepts
+// any number of arguments.
+func newBuiltinFn(name string, arity int, callFn func(i *Interpreter, args []any) (any, error)) builtinFn {
+	return builtinFn{
+		arityFn:  func() int { return arity },
+		callFn:   callFn,
+		stringFn: func() string { return fmt.Sprintf("<native fn %s>", name) },
+	}
+}
+
 func defineNativeFns(env *environment) {
 	defineClockFn(env)
 	defineArrayFns(env)
+	defineCollectionFns(env)
+	defineMapFns(env)
+	defineConcurrencyFns(env)
+	defineTimerFns(env)
+	defineFsModule(env)
+	defineJsonModule(env)
+	defineRegexModule(env)
+	defineDatetimeModule(env)
+	defineRandomModule(env)
+	defineSystemFns(env)
+	defineProcessFns(env)
+	defineInputFns(env)
+	defineFormatFns(env)
 }
 
 func defineClockFn(env *environment) {
+	// clock returns the seconds since the Unix epoch, with a fraction.
 	env.define("clock", builtinFn{
 		arityFn: func() int { return 0 },
 		callFn: func(i *Interpreter, args []any) (any, error) {
-			return time.Now().Unix(), nil
+			return float64(i.loop.clock.Now().UnixNano()) / float64(time.Second), nil
 		},
 		stringFn: func() string { return "<native fn clock>" },
 	})
+
+	// nanotime returns the nanoseconds elapsed since the interpreter started,
+	// from a monotonic clock, for measuring durations.
+	env.define("nanotime", newBuiltinFn("nanotime", 0, func(i *Interpreter, args []any) (any, error) {
+		return int(i.loop.clock.Now().Sub(i.loop.started)), nil
+	}))
 }
 
 func defineArrayFns(env *environment) {
 	env.define("len", builtinFn{
 		arityFn: func() int { return 1 },
 		callFn: func(i *Interpreter, args []any) (any, error) {
-			arr, ok := args[0].(*array)
-			if !ok {
-				return nil, builtinErrMsg("Can only call 'len' on arrays.")
+			switch v := args[0].(type) {
+			case *array:
+				return v.Len(), nil
+			case *loxMap:
+				return v.Len(), nil
+			default:
+				return nil, builtinErrMsg("Can only call 'len' on arrays and maps.")
 			}
-			return arr.Len(), nil
 		},
 		stringFn: func() string { return "<native fn len>" },
 	})
@@ -57,12 +96,25 @@ func defineArrayFns(env *environment) {
 			if !ok {
 				return nil, builtinErrMsg("Can only call 'append' on arrays.")
 			}
+			if err := i.alloc(int64(len(args)-1) * valueSize); err != nil {
+				return nil, err
+			}
 			arr.Append(args[1:]...)
 			return nil, nil
 		},
 	})
 }
 
+// stringArg returns the argument at idx as a string. what describes the
+// argument in the error, such as "Path".
+func stringArg(name, what string, args []any, idx int) (string, error) {
+	s, ok := args[idx].(string)
+	if !ok {
+		return "", builtinErrMsg(fmt.Sprintf("%s passed to '%s' must be a string.", what, name))
+	}
+	return s, nil
+}
+
 type builtinErrMsg string
 
 func (em builtinErrMsg) Error() string {
@@ -0,0 +1,83 @@
+package lox
+
+import "fmt"
+
+// Capability is a group of native functions and modules that give scripts
+// access to the world outside the interpreter. See WithCapabilities.
+type Capability string
+
+const (
+	// CapClock is clock, nanotime and the datetime module.
+	CapClock Capability = "clock"
+	// CapTimers is setTimeout, setInterval, clearTimeout, clearInterval, sleep
+	// and gather.
+	CapTimers Capability = "timers"
+	// CapConcurrency is spawn expressions, channel, waitGroup and select.
+	CapConcurrency Capability = "concurrency"
+	// CapFS is the fs module.
+	CapFS Capability = "fs"
+	// CapExec is exec and startProcess.
+	CapExec Capability = "exec"
+	// CapEnv is env and setenv.
+	CapEnv Capability = "env"
+	// CapStdin is readLine, readToken and readAll.
+	CapStdin Capability = "stdin"
+	// CapRandom is the random module.
+	CapRandom Capability = "random"
+)
+
+// capabilityGlobals are the globals defined by each capability.
+var capabilityGlobals = map[Capability][]string{
+	CapClock:       {"clock", "nanotime", "datetime"},
+	CapTimers:      {"setTimeout", "setInterval", "clearTimeout", "clearInterval", "sleep", "gather"},
+	CapConcurrency: {"channel", "waitGroup", "select"},
+	CapFS:          {"fs"},
+	CapExec:        {"exec", "startProcess"},
+	CapEnv:         {"env", "setenv"},
+	CapStdin:       {"readLine", "readToken", "readAll"},
+	CapRandom:      {"random"},
+}
+
+// allows reports whether scripts run by i have the capability c.
+func (i *Interpreter) allows(c Capability) bool {
+	return i.caps == nil || i.caps[c]
+}
+
+// denyCapabilities replaces the globals of the capabilities that i doesn't
+// have with natives that raise a permission error when they are used.
+func (i *Interpreter) denyCapabilities() {
+	for c, names := range capabilityGlobals {
+		if i.allows(c) {
+			continue
+		}
+		for _, name := range names {
+			i.globals.define(name, deniedNative{name})
+		}
+	}
+}
+
+func permissionDenied(name string) error {
+	return builtinErrMsg(fmt.Sprintf("Permission denied: '%s' is disabled.", name))
+}
+
+// deniedNative takes the place of a native function or module that scripts
+// are not allowed to use.
+type deniedNative struct {
+	name string
+}
+
+func (d deniedNative) call(i *Interpreter, args []any) (any, error) {
+	return nil, permissionDenied(d.name)
+}
+
+func (d deniedNative) arity() int {
+	return anyArity
+}
+
+func (d deniedNative) get(name token) (any, error) {
+	return nil, NewRuntimeError(name, permissionDenied(d.name).Error())
+}
+
+func (d deniedNative) String() string {
+	return fmt.Sprintf("<denied %s>", d.name)
+}
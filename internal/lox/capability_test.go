@@ -0,0 +1,81 @@
+package lox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_capabilities(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		caps       []Capability
+		code       string
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			desc:       "all_by_default",
+			code:       `print clock() > 0; print random.int(1, 1);`,
+			wantStdout: "true\n1\n",
+		},
+		{
+			desc:       "core_always_available",
+			caps:       []Capability{},
+			code:       `print json.stringify(map([1, 2], x => x * 2), 0); println(format("{}!", "ok"));`,
+			wantStdout: "[2,4]\nok!\n",
+		},
+		{
+			desc:       "denied_module",
+			caps:       []Capability{CapClock},
+			code:       `print clock() > 0; print fs; fs.readFile("secret.txt");`,
+			wantStdout: "true\n<denied fs>\n",
+			wantStderr: "[line 1] Runtime Error at 'readFile': Permission denied: 'fs' is disabled.\n",
+		},
+		{
+			desc:       "denied_function",
+			caps:       []Capability{CapFS},
+			code:       `exec("echo", ["hi"]);`,
+			wantStderr: "[line 1] Runtime Error at ')': Permission denied: 'exec' is disabled.\n",
+		},
+		{
+			desc:       "denied_clock",
+			caps:       []Capability{CapTimers},
+			code:       `var t = datetime.now();`,
+			wantStderr: "[line 1] Runtime Error at 'now': Permission denied: 'datetime' is disabled.\n",
+		},
+		{
+			desc: "denied_spawn",
+			caps: []Capability{CapTimers},
+			code: `fn work() { return 1; }
+			var t = spawn work();`,
+			wantStderr: "[line 2] Runtime Error at 'spawn': Permission denied: 'spawn' is disabled.\n",
+		},
+		{
+			desc:       "allowed_timers",
+			caps:       []Capability{CapTimers},
+			code:       `setTimeout(fn() { print "later"; }, 0); print "now";`,
+			wantStdout: "now\nlater\n",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+			var stdout, stderr strings.Builder
+			opts := []RuntimeOption{WithStdout(&stdout), WithStderr(&stderr)}
+			if tC.caps != nil {
+				opts = append(opts, WithCapabilities(tC.caps...))
+			}
+			er := NewLoxErrorReporter()
+			interpreter := NewInterpreter(er)
+			resolver := NewResolver(er, interpreter)
+			rt := NewRuntime(er, interpreter, resolver, opts...)
+
+			rt.run([]byte(tC.code))
+			assert.Equal(t, tC.wantStdout, stdout.String())
+			assert.Equal(t, tC.wantStderr, withoutSnippet(stderr.String()))
+		})
+	}
+}
@@ -23,10 +23,15 @@ func newClass(name string, superclass *class, methods map[string]*function) *cla
 }
 
 func (c *class) call(i *Interpreter, args []any) (any, error) {
+	if err := i.alloc(instanceSize); err != nil {
+		return nil, err
+	}
 	instance := newInstance(c)
 	if initializer, ok := c.methods["init"]; ok {
 		// discard returned values from initializer when creating new a instance
-		initializer.bind(instance).call(i, args)
+		if _, err := initializer.bind(instance).call(i, args); err != nil {
+			return nil, err
+		}
 	}
 	return instance, nil
 }
@@ -0,0 +1,360 @@
+package lox
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// arrayArg returns the argument at idx as an array, or an error naming the
+// native function it was passed to.
+func arrayArg(name string, args []any, idx int) (*array, error) {
+	arr, ok := args[idx].(*array)
+	if !ok {
+		return nil, builtinErrMsg(fmt.Sprintf("Can only call '%s' on arrays.", name))
+	}
+	return arr, nil
+}
+
+// callbackArg returns the argument at idx as a function that can be called with
+// the given number of arguments.
+func callbackArg(name string, args []any, idx, arity int) (callable, error) {
+	fn, ok := args[idx].(callable)
+	if ok && (fn.arity() == arity || fn.arity() == -1 && arity > 0 || fn.arity() == anyArity) {
+		return fn, nil
+	}
+	argCount := map[int]string{1: "one argument", 2: "two arguments"}[arity]
+	return nil, builtinErrMsg(fmt.Sprintf("Can only call '%s' with a function that takes %s.", name, argCount))
+}
+
+func intArg(name string, args []any, idx int) (int, error) {
+	n, ok := args[idx].(int)
+	if !ok {
+		return 0, builtinErrMsg(fmt.Sprintf("Index passed to '%s' must be an integer.", name))
+	}
+	return n, nil
+}
+
+// compare orders numbers and strings, for sorting without a comparator.
+func (i *Interpreter) compare(left, right any) (int, error) {
+	if leftNum, rightNum, err := i.assertFloatOperands(left, right); err == nil {
+		switch {
+		case leftNum < rightNum:
+			return -1, nil
+		case leftNum > rightNum:
+			return 1, nil
+		}
+		return 0, nil
+	}
+	leftStr, leftOk := left.(string)
+	rightStr, rightOk := right.(string)
+	if leftOk && rightOk {
+		return strings.Compare(leftStr, rightStr), nil
+	}
+	return 0, builtinErrMsg("Can only sort numbers or strings without a comparator.")
+}
+
+// sortItems sorts items in place, keeping the order of equal items. cmp is
+// called with two items and must return a negative number if the first comes
+// before the second, a positive number if it comes after, and zero otherwise.
+// The first error from cmp stops the sort.
+func sortItems(items []any, cmp func(a, b any) (any, error)) error {
+	var sortErr error
+	sort.SliceStable(items, func(x, y int) bool {
+		if sortErr != nil {
+			return false
+		}
+		res, err := cmp(items[x], items[y])
+		if err != nil {
+			sortErr = err
+			return false
+		}
+		switch n := res.(type) {
+		case int:
+			return n < 0
+		case float64:
+			return n < 0
+		default:
+			sortErr = builtinErrMsg("Comparator must return a number.")
+			return false
+		}
+	})
+	return sortErr
+}
+
+func defineCollectionFns(env *environment) {
+	// iterate defines a native function that takes an array and a function of
+	// one argument, and runs over a copy of the array's items.
+	iterate := func(name string, run func(i *Interpreter, fn callable, items []any) (any, error)) builtinFn {
+		return newBuiltinFn(name, 2, func(i *Interpreter, args []any) (any, error) {
+			arr, err := arrayArg(name, args, 0)
+			if err != nil {
+				return nil, err
+			}
+			fn, err := callbackArg(name, args, 1, 1)
+			if err != nil {
+				return nil, err
+			}
+			return run(i, fn, arr.Items())
+		})
+	}
+
+	env.define("map", iterate("map", func(i *Interpreter, fn callable, items []any) (any, error) {
+		out := newArray()
+		for _, item := range items {
+			val, err := i.call(i.site, fn, []any{item})
+			if err != nil {
+				return nil, err
+			}
+			out.Append(val)
+		}
+		return out, nil
+	}))
+
+	env.define("filter", iterate("filter", func(i *Interpreter, fn callable, items []any) (any, error) {
+		out := newArray()
+		for _, item := range items {
+			keep, err := i.call(i.site, fn, []any{item})
+			if err != nil {
+				return nil, err
+			}
+			if i.isTruthy(keep) {
+				out.Append(item)
+			}
+		}
+		return out, nil
+	}))
+
+	env.define("each", iterate("each", func(i *Interpreter, fn callable, items []any) (any, error) {
+		for _, item := range items {
+			if _, err := i.call(i.site, fn, []any{item}); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}))
+
+	env.define("find", iterate("find", func(i *Interpreter, fn callable, items []any) (any, error) {
+		for _, item := range items {
+			found, err := i.call(i.site, fn, []any{item})
+			if err != nil {
+				return nil, err
+			}
+			if i.isTruthy(found) {
+				return item, nil
+			}
+		}
+		return nil, nil
+	}))
+
+	env.define("any", iterate("any", func(i *Interpreter, fn callable, items []any) (any, error) {
+		for _, item := range items {
+			val, err := i.call(i.site, fn, []any{item})
+			if err != nil {
+				return nil, err
+			}
+			if i.isTruthy(val) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}))
+
+	env.define("all", iterate("all", func(i *Interpreter, fn callable, items []any) (any, error) {
+		for _, item := range items {
+			val, err := i.call(i.site, fn, []any{item})
+			if err != nil {
+				return nil, err
+			}
+			if !i.isTruthy(val) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}))
+
+	env.define("reduce", newBuiltinFn("reduce", 3, func(i *Interpreter, args []any) (any, error) {
+		arr, err := arrayArg("reduce", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		fn, err := callbackArg("reduce", args, 1, 2)
+		if err != nil {
+			return nil, err
+		}
+		acc := args[2]
+		for _, item := range arr.Items() {
+			acc, err = i.call(i.site, fn, []any{acc, item})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return acc, nil
+	}))
+
+	// sort returns a sorted copy of the array. Numbers and strings are sorted in
+	// ascending order, unless a comparator function is passed as well.
+	env.define("sort", newBuiltinFn("sort", -1, func(i *Interpreter, args []any) (any, error) {
+		if len(args) > 2 {
+			return nil, builtinErrMsg(fmt.Sprintf("Expected at most 2 arguments but got %d.", len(args)))
+		}
+		arr, err := arrayArg("sort", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		cmp := func(a, b any) (any, error) {
+			return i.compare(a, b)
+		}
+		if len(args) == 2 {
+			fn, err := callbackArg("sort", args, 1, 2)
+			if err != nil {
+				return nil, err
+			}
+			cmp = func(a, b any) (any, error) {
+				return i.call(i.site, fn, []any{a, b})
+			}
+		}
+		items := arr.Items()
+		if err := sortItems(items, cmp); err != nil {
+			return nil, err
+		}
+		out := newArray()
+		out.Append(items...)
+		return out, nil
+	}))
+
+	env.define("zip", newBuiltinFn("zip", 2, func(i *Interpreter, args []any) (any, error) {
+		left, err := arrayArg("zip", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		right, err := arrayArg("zip", args, 1)
+		if err != nil {
+			return nil, err
+		}
+		leftItems, rightItems := left.Items(), right.Items()
+		out := newArray()
+		for idx := range min(len(leftItems), len(rightItems)) {
+			pair := newArray()
+			pair.Append(leftItems[idx], rightItems[idx])
+			out.Append(pair)
+		}
+		return out, nil
+	}))
+
+	env.define("enumerate", newBuiltinFn("enumerate", 1, func(i *Interpreter, args []any) (any, error) {
+		arr, err := arrayArg("enumerate", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		out := newArray()
+		for idx, item := range arr.Items() {
+			pair := newArray()
+			pair.Append(idx, item)
+			out.Append(pair)
+		}
+		return out, nil
+	}))
+
+	env.define("reverse", newBuiltinFn("reverse", 1, func(i *Interpreter, args []any) (any, error) {
+		arr, err := arrayArg("reverse", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		items := arr.Items()
+		out := newArray()
+		for idx := len(items) - 1; idx >= 0; idx-- {
+			out.Append(items[idx])
+		}
+		return out, nil
+	}))
+
+	env.define("indexOf", newBuiltinFn("indexOf", 2, func(i *Interpreter, args []any) (any, error) {
+		arr, err := arrayArg("indexOf", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		for idx, item := range arr.Items() {
+			if i.isEqual(item, args[1]) {
+				return idx, nil
+			}
+		}
+		return -1, nil
+	}))
+
+	env.define("contains", newBuiltinFn("contains", 2, func(i *Interpreter, args []any) (any, error) {
+		arr, err := arrayArg("contains", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range arr.Items() {
+			if i.isEqual(item, args[1]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}))
+
+	env.define("pop", newBuiltinFn("pop", 1, func(i *Interpreter, args []any) (any, error) {
+		arr, err := arrayArg("pop", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return arr.Pop()
+	}))
+
+	env.define("insert", newBuiltinFn("insert", 3, func(i *Interpreter, args []any) (any, error) {
+		arr, err := arrayArg("insert", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		idx, err := intArg("insert", args, 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := i.alloc(valueSize); err != nil {
+			return nil, err
+		}
+		return nil, arr.Insert(idx, args[2])
+	}))
+
+	env.define("remove", newBuiltinFn("remove", 2, func(i *Interpreter, args []any) (any, error) {
+		arr, err := arrayArg("remove", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		idx, err := intArg("remove", args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return arr.Remove(idx)
+	}))
+
+	env.define("concat", newBuiltinFn("concat", -1, func(i *Interpreter, args []any) (any, error) {
+		out := newArray()
+		for idx := range args {
+			arr, err := arrayArg("concat", args, idx)
+			if err != nil {
+				return nil, err
+			}
+			out.Append(arr.Items()...)
+		}
+		return out, nil
+	}))
+
+	env.define("join", newBuiltinFn("join", 2, func(i *Interpreter, args []any) (any, error) {
+		arr, err := arrayArg("join", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		sep, ok := args[1].(string)
+		if !ok {
+			return nil, builtinErrMsg("Separator passed to 'join' must be a string.")
+		}
+		parts := make([]string, 0, arr.Len())
+		for _, item := range arr.Items() {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, sep), nil
+	}))
+}
@@ -0,0 +1,163 @@
+package lox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_collection(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		code    string
+		input   string
+		want    any
+		wantErr bool
+	}{
+		{
+			desc:  "map",
+			code:  "var xs = [1, 2, 3];",
+			input: `join(map(xs, x => x * 2), ",")`,
+			want:  "2,4,6",
+		},
+		{
+			desc:  "filter",
+			code:  "var xs = [1, 2, 3, 4];",
+			input: `join(filter(xs, x => x > 2), ",")`,
+			want:  "3,4",
+		},
+		{
+			desc:  "reduce",
+			code:  "var xs = [1, 2, 3, 4];",
+			input: "reduce(xs, (acc, x) => acc + x, 10)",
+			want:  20,
+		},
+		{
+			desc: "each",
+			code: `var total = 0;
+			each([1, 2, 3], fn(x) { total = total + x; });`,
+			input: "total",
+			want:  6,
+		},
+		{
+			desc:  "find",
+			code:  "var xs = [1, 5, 8];",
+			input: "find(xs, x => x > 4)",
+			want:  5,
+		},
+		{
+			desc:  "find_missing",
+			code:  "var xs = [1, 5, 8];",
+			input: "find(xs, x => x > 10)",
+			want:  nil,
+		},
+		{
+			desc:  "any_and_all",
+			code:  "var xs = [1, 5, 8];",
+			input: "any(xs, x => x > 7) and !all(xs, x => x > 1)",
+			want:  true,
+		},
+		{
+			desc:  "zip_stops_at_shortest",
+			code:  `var pairs = zip([1, 2, 3], ["a", "b"]);`,
+			input: `join(map(pairs, p => join(p, "")), ",")`,
+			want:  "1a,2b",
+		},
+		{
+			desc:  "enumerate",
+			code:  `var pairs = enumerate(["a", "b"]);`,
+			input: `join(map(pairs, p => join(p, ":")), ",")`,
+			want:  "0:a,1:b",
+		},
+		{
+			desc:  "reverse_copies",
+			code:  `var xs = [1, 2, 3]; var ys = reverse(xs);`,
+			input: `join(concat(xs, ys), ",")`,
+			want:  "1,2,3,3,2,1",
+		},
+		{
+			desc:  "contains_and_index_of",
+			code:  `var xs = [1, "two", 3.0];`,
+			input: `contains(xs, 3) and indexOf(xs, "two") == 1 and indexOf(xs, 4) == -1`,
+			want:  true,
+		},
+		{
+			desc: "pop_insert_remove",
+			code: `var xs = [1, 2, 3];
+			var last = pop(xs);
+			insert(xs, 0, last);
+			var removed = remove(xs, -1);`,
+			input: `join(xs, ",") + " " + removed`,
+			want:  "3,1 2",
+		},
+		{
+			desc:    "pop_empty",
+			code:    "var xs = [];",
+			input:   "pop(xs)",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:    "remove_out_of_range",
+			code:    "var xs = [1];",
+			input:   "remove(xs, 1)",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:  "sort_default",
+			code:  `var xs = sort([3, 1.5, 2]);`,
+			input: `join(xs, ",")`,
+			want:  "1.5,2,3",
+		},
+		{
+			desc: "sort_comparator_is_stable",
+			code: `var xs = [[2, "a"], [1, "b"], [2, "c"], [1, "d"]];
+			var sorted = sort(xs, (a, b) => a[0] - b[0]);`,
+			input: `join(map(sorted, p => p[1]), "")`,
+			want:  "bdac",
+		},
+		{
+			desc:    "sort_mixed_without_comparator",
+			code:    "var xs = [1, \"a\"];",
+			input:   "sort(xs)",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:    "sort_comparator_must_return_number",
+			code:    "var xs = [1, 2];",
+			input:   "sort(xs, (a, b) => a < b)",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:    "callback_runtime_error",
+			code:    "var xs = [1, 2];",
+			input:   `map(xs, x => x - "a")`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:    "callback_wrong_arity",
+			code:    "var xs = [1, 2];",
+			input:   "map(xs, (a, b) => a)",
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := runScript(t, tC.code, tC.input)
+			assert.Equal(t, tC.want, got)
+			if tC.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package lox
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// task is the handle returned by a spawn expression. The spawned call runs on
+// its own goroutine with a forked interpreter.
+type task struct {
+	done   chan struct{}
+	result any
+	err    error
+}
+
+func newTask(i *Interpreter, paren token, function callable, args []any) *task {
+	t := &task{done: make(chan struct{})}
+	ti := i.fork()
+	ti.depth = i.depth
+	go func() {
+		defer close(t.done)
+		t.result, t.err = ti.call(paren, function, args)
+	}()
+	return t
+}
+
+// join waits for the spawned call to finish and returns its result.
+func (t *task) join(i *Interpreter) (any, error) {
+	select {
+	case <-t.done:
+		return t.result, t.err
+	case <-i.ctx.Done():
+		return nil, i.interruption()
+	}
+}
+
+func (t *task) isDone() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *task) get(name token) (any, error) {
+	switch name.lexeme {
+	case "join":
+		return newBuiltinFn("join", 0, func(i *Interpreter, args []any) (any, error) {
+			return t.join(i)
+		}), nil
+	case "done":
+		return newBuiltinFn("done", 0, func(i *Interpreter, args []any) (any, error) {
+			return t.isDone(), nil
+		}), nil
+	}
+	return nil, NewRuntimeError(name, fmt.Sprintf("Undefined properties '%s'", name.lexeme))
+}
+
+func (t *task) String() string {
+	return "<task>"
+}
+
+type channel struct {
+	ch chan any
+}
+
+func newChannel(capacity int) *channel {
+	return &channel{ch: make(chan any, capacity)}
+}
+
+func (c *channel) send(i *Interpreter, val any) (err error) {
+	defer func() {
+		if recover() != nil {
+			err = builtinErrMsg("Can't send on a closed channel.")
+		}
+	}()
+	select {
+	case c.ch <- val:
+		return nil
+	case <-i.ctx.Done():
+		return i.interruption()
+	}
+}
+
+// recv waits for a value from the channel. It returns false once the channel
+// is closed and drained.
+func (c *channel) recv(i *Interpreter) (any, bool, error) {
+	select {
+	case val, ok := <-c.ch:
+		return val, ok, nil
+	case <-i.ctx.Done():
+		return nil, false, i.interruption()
+	}
+}
+
+func (c *channel) close() (err error) {
+	defer func() {
+		if recover() != nil {
+			err = builtinErrMsg("Channel is already closed.")
+		}
+	}()
+	close(c.ch)
+	return nil
+}
+
+func (c *channel) iterator(i *Interpreter) iterator {
+	return &channelIterator{channel: c, i: i}
+}
+
+type channelIterator struct {
+	channel *channel
+	i       *Interpreter
+}
+
+func (it *channelIterator) next() (any, bool, error) {
+	return it.channel.recv(it.i)
+}
+
+func (c *channel) get(name token) (any, error) {
+	switch name.lexeme {
+	case "send":
+		return newBuiltinFn("send", 1, func(i *Interpreter, args []any) (any, error) {
+			return nil, c.send(i, args[0])
+		}), nil
+	case "recv":
+		return newBuiltinFn("recv", 0, func(i *Interpreter, args []any) (any, error) {
+			val, _, err := c.recv(i)
+			return val, err
+		}), nil
+	case "close":
+		return newBuiltinFn("close", 0, func(i *Interpreter, args []any) (any, error) {
+			return nil, c.close()
+		}), nil
+	}
+	return nil, NewRuntimeError(name, fmt.Sprintf("Undefined properties '%s'", name.lexeme))
+}
+
+func (c *channel) String() string {
+	return "<channel>"
+}
+
+// waitGroup counts tasks that are running. zero is closed while the count is
+// zero, so that waiting can be interrupted.
+type waitGroup struct {
+	mu    sync.Mutex
+	count int
+	zero  chan struct{}
+}
+
+func newWaitGroup() *waitGroup {
+	w := &waitGroup{zero: make(chan struct{})}
+	close(w.zero)
+	return w
+}
+
+func (w *waitGroup) add(delta int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.count+delta < 0 {
+		return builtinErrMsg("Negative wait group counter.")
+	}
+	if w.count == 0 && delta > 0 {
+		w.zero = make(chan struct{})
+	}
+	w.count += delta
+	if w.count == 0 && delta < 0 {
+		close(w.zero)
+	}
+	return nil
+}
+
+// wait waits until the count is zero.
+func (w *waitGroup) wait(i *Interpreter) error {
+	w.mu.Lock()
+	zero := w.zero
+	w.mu.Unlock()
+	select {
+	case <-zero:
+		return nil
+	case <-i.ctx.Done():
+		return i.interruption()
+	}
+}
+
+func (w *waitGroup) get(name token) (any, error) {
+	switch name.lexeme {
+	case "add":
+		return newBuiltinFn("add", 1, func(i *Interpreter, args []any) (any, error) {
+			delta, ok := args[0].(int)
+			if !ok {
+				return nil, builtinErrMsg("Wait group delta must be an integer.")
+			}
+			return nil, w.add(delta)
+		}), nil
+	case "done":
+		return newBuiltinFn("done", 0, func(i *Interpreter, args []any) (any, error) {
+			return nil, w.add(-1)
+		}), nil
+	case "wait":
+		return newBuiltinFn("wait", 0, func(i *Interpreter, args []any) (any, error) {
+			return nil, w.wait(i)
+		}), nil
+	}
+	return nil, NewRuntimeError(name, fmt.Sprintf("Undefined properties '%s'", name.lexeme))
+}
+
+func (w *waitGroup) String() string {
+	return "<wait group>"
+}
+
+func defineConcurrencyFns(env *environment) {
+	env.define("channel", newBuiltinFn("channel", 1, func(i *Interpreter, args []any) (any, error) {
+		capacity, ok := args[0].(int)
+		if !ok || capacity < 0 {
+			return nil, builtinErrMsg("Channel capacity must be a non-negative integer.")
+		}
+		return newChannel(capacity), nil
+	}))
+
+	env.define("waitGroup", newBuiltinFn("waitGroup", 0, func(i *Interpreter, args []any) (any, error) {
+		return newWaitGroup(), nil
+	}))
+
+	// select waits until one of the channels in the given array can be received
+	// from, and returns an array of that channel's index and the received value.
+	// The value is nil if the channel was closed.
+	env.define("select", newBuiltinFn("select", 1, func(i *Interpreter, args []any) (any, error) {
+		arr, ok := args[0].(*array)
+		if !ok || arr.Len() == 0 {
+			return nil, builtinErrMsg("Can only select on a non-empty array of channels.")
+		}
+		cases := make([]reflect.SelectCase, arr.Len(), arr.Len()+1)
+		for idx := range cases {
+			c, ok := arr.Get(idx).(*channel)
+			if !ok {
+				return nil, builtinErrMsg("Can only select on a non-empty array of channels.")
+			}
+			cases[idx] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c.ch)}
+		}
+		// The last case stops waiting once the run is interrupted.
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(i.ctx.Done())})
+		chosen, val, ok := reflect.Select(cases)
+		if chosen == arr.Len() {
+			return nil, i.interruption()
+		}
+		out := newArray()
+		if ok {
+			out.Append(chosen, val.Interface())
+		} else {
+			out.Append(chosen, nil)
+		}
+		return out, nil
+	}))
+}
@@ -0,0 +1,101 @@
+package lox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_concurrency(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		code    string
+		input   string
+		want    any
+		wantErr bool
+	}{
+		{
+			desc: "spawn_join",
+			code: `fn square(x) { return x * x; }
+			var t = spawn square(7);`,
+			input: "t.join()",
+			want:  49,
+		},
+		{
+			desc: "spawn_join_runtime_error",
+			code: `fn fail() { return 1 - "one"; }
+			var t = spawn fail();`,
+			input:   "t.join()",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc: "spawn_closure_shares_environment",
+			code: `var total = 0;
+			var wg = waitGroup();
+			fn add(n) {
+				total = total + n;
+				wg.done();
+			}
+			wg.add(1);
+			spawn add(5);
+			wg.wait();`,
+			input: "total",
+			want:  5,
+		},
+		{
+			desc: "channel_send_recv",
+			code: `var ch = channel(1);
+			ch.send("hello");`,
+			input: "ch.recv()",
+			want:  "hello",
+		},
+		{
+			desc: "channel_iterate_until_closed",
+			code: `var ch = channel(0);
+			fn produce() {
+				for var i = 1; i <= 4; i = i + 1 {
+					ch.send(i);
+				}
+				ch.close();
+			}
+			spawn produce();
+			var sum = 0;
+			for v in ch {
+				sum = sum + v;
+			}`,
+			input: "sum",
+			want:  10,
+		},
+		{
+			desc: "channel_send_on_closed",
+			code: `var ch = channel(1);
+			ch.close();`,
+			input:   `ch.send(1)`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc: "select_ready_channel",
+			code: `var a = channel(1);
+			var b = channel(1);
+			b.send("b");`,
+			input: "select([a, b])[0]",
+			want:  1,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := runScript(t, tC.code, tC.input)
+			assert.Equal(t, tC.want, got)
+			if tC.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package lox
+
+import "errors"
+
+// coroutine runs a body on its own goroutine and passes control back and forth
+// with the caller, so that only one of them is ever running at a time.
+type coroutine struct {
+	body     func(co *coroutine) (any, error)
+	resumeCh chan any
+	yieldCh  chan coroutineResult
+	started  bool
+	done     bool
+}
+
+type coroutineResult struct {
+	value any
+	done  bool
+	err   error
+}
+
+func newCoroutine(body func(co *coroutine) (any, error)) *coroutine {
+	return &coroutine{
+		body:     body,
+		resumeCh: make(chan any),
+		yieldCh:  make(chan coroutineResult),
+	}
+}
+
+// resume runs the coroutine until it yields or finishes. The value passed in
+// is returned by the yield the coroutine is suspended at, and is ignored when
+// the coroutine is first started. It reports whether the coroutine is done,
+// in which case the returned value is the one returned by its body.
+func (co *coroutine) resume(val any) (any, bool, error) {
+	if co.done {
+		return nil, true, nil
+	}
+	if !co.started {
+		co.started = true
+		go co.run()
+	} else {
+		co.resumeCh <- val
+	}
+	res := <-co.yieldCh
+	if res.done {
+		co.done = true
+	}
+	return res.value, res.done, res.err
+}
+
+func (co *coroutine) run() {
+	val, err := co.body(co)
+	var closeErr *coroutineClose
+	if errors.As(err, &closeErr) {
+		err = nil
+	}
+	co.yieldCh <- coroutineResult{value: val, done: true, err: err}
+}
+
+// yield suspends the coroutine, handing val to the caller of resume. It must
+// only be called from the coroutine's own goroutine.
+func (co *coroutine) yield(val any) (any, error) {
+	co.yieldCh <- coroutineResult{value: val}
+	in, ok := <-co.resumeCh
+	if !ok {
+		return nil, &coroutineClose{}
+	}
+	return in, nil
+}
+
+// close stops a suspended coroutine, unwinding its body.
+func (co *coroutine) close() {
+	if co.done {
+		return
+	}
+	co.done = true
+	if !co.started {
+		return
+	}
+	close(co.resumeCh)
+	<-co.yieldCh
+}
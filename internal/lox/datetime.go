@@ -0,0 +1,220 @@
+package lox
+
+import (
+	"fmt"
+	"time"
+	// Embed the time zone database, so that zones can be loaded on systems
+	// without one.
+	_ "time/tzdata"
+)
+
+// dateTime is an instant in time with a time zone, created by the datetime
+// module. Durations are numbers of milliseconds, like the delays of timers.
+type dateTime struct {
+	t time.Time
+}
+
+// durationMillis converts d to a number of milliseconds, which is an int when
+// it is whole.
+func durationMillis(d time.Duration) any {
+	if d%time.Millisecond == 0 {
+		return int(d / time.Millisecond)
+	}
+	return float64(d) / float64(time.Millisecond)
+}
+
+func dateTimeArg(name string, args []any, idx int) (*dateTime, error) {
+	dt, ok := args[idx].(*dateTime)
+	if !ok {
+		return nil, builtinErrMsg(fmt.Sprintf("Argument passed to '%s' must be a datetime.", name))
+	}
+	return dt, nil
+}
+
+func loadZone(name string) (*time.Location, error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, builtinErrMsg(fmt.Sprintf("Unknown time zone '%s'.", name))
+	}
+	return loc, nil
+}
+
+func (dt *dateTime) get(name token) (any, error) {
+	// Methods without arguments that return a part of the date or time.
+	parts := map[string]func() any{
+		"year":       func() any { return dt.t.Year() },
+		"month":      func() any { return int(dt.t.Month()) },
+		"day":        func() any { return dt.t.Day() },
+		"hour":       func() any { return dt.t.Hour() },
+		"minute":     func() any { return dt.t.Minute() },
+		"second":     func() any { return dt.t.Second() },
+		"nanosecond": func() any { return dt.t.Nanosecond() },
+		"weekday":    func() any { return dt.t.Weekday().String() },
+		"yearDay":    func() any { return dt.t.YearDay() },
+		"zone":       func() any { return dt.t.Location().String() },
+		"unix":       func() any { return float64(dt.t.UnixNano()) / float64(time.Second) },
+		"utc":        func() any { return &dateTime{t: dt.t.UTC()} },
+	}
+	if part, ok := parts[name.lexeme]; ok {
+		return newBuiltinFn(name.lexeme, 0, func(i *Interpreter, args []any) (any, error) {
+			return part(), nil
+		}), nil
+	}
+
+	switch name.lexeme {
+	case "format":
+		return newBuiltinFn("format", 1, func(i *Interpreter, args []any) (any, error) {
+			layout, err := stringArg("format", "Layout", args, 0)
+			if err != nil {
+				return nil, err
+			}
+			return dt.t.Format(layout), nil
+		}), nil
+	case "inZone":
+		return newBuiltinFn("inZone", 1, func(i *Interpreter, args []any) (any, error) {
+			zone, err := stringArg("inZone", "Time zone", args, 0)
+			if err != nil {
+				return nil, err
+			}
+			loc, err := loadZone(zone)
+			if err != nil {
+				return nil, err
+			}
+			return &dateTime{t: dt.t.In(loc)}, nil
+		}), nil
+	case "add":
+		return newBuiltinFn("add", 1, func(i *Interpreter, args []any) (any, error) {
+			d, err := millis(args[0])
+			if err != nil {
+				return nil, builtinErrMsg("Duration must be a number of milliseconds.")
+			}
+			return &dateTime{t: dt.t.Add(d)}, nil
+		}), nil
+	case "addDate":
+		return newBuiltinFn("addDate", 3, func(i *Interpreter, args []any) (any, error) {
+			years, ok1 := args[0].(int)
+			months, ok2 := args[1].(int)
+			days, ok3 := args[2].(int)
+			if !ok1 || !ok2 || !ok3 {
+				return nil, builtinErrMsg("Years, months and days must be integers.")
+			}
+			return &dateTime{t: dt.t.AddDate(years, months, days)}, nil
+		}), nil
+	case "sub":
+		return newBuiltinFn("sub", 1, func(i *Interpreter, args []any) (any, error) {
+			other, err := dateTimeArg("sub", args, 0)
+			if err != nil {
+				return nil, err
+			}
+			return durationMillis(dt.t.Sub(other.t)), nil
+		}), nil
+	case "before", "after", "equal":
+		return newBuiltinFn(name.lexeme, 1, func(i *Interpreter, args []any) (any, error) {
+			other, err := dateTimeArg(name.lexeme, args, 0)
+			if err != nil {
+				return nil, err
+			}
+			switch name.lexeme {
+			case "before":
+				return dt.t.Before(other.t), nil
+			case "after":
+				return dt.t.After(other.t), nil
+			default:
+				return dt.t.Equal(other.t), nil
+			}
+		}), nil
+	}
+	return nil, NewRuntimeError(name, fmt.Sprintf("Undefined properties '%s'", name.lexeme))
+}
+
+func (dt *dateTime) String() string {
+	return dt.t.Format(time.RFC3339Nano)
+}
+
+func defineDatetimeModule(env *environment) {
+	m := newModule("datetime")
+
+	// Layouts for format and parse, which use Go's reference time.
+	m.members["RFC3339"] = time.RFC3339
+	m.members["DATE"] = time.DateOnly
+	m.members["TIME"] = time.TimeOnly
+	m.members["DATETIME"] = time.DateTime
+
+	m.define("now", 0, func(i *Interpreter, args []any) (any, error) {
+		return &dateTime{t: i.loop.clock.Now()}, nil
+	})
+
+	// date takes a year, month and day, optionally followed by the hour,
+	// minute and second, and returns that time in UTC.
+	m.define("date", -1, func(i *Interpreter, args []any) (any, error) {
+		if len(args) != 3 && len(args) != 6 {
+			return nil, builtinErrMsg(fmt.Sprintf("Expected 3 or 6 arguments but got %d.", len(args)))
+		}
+		parts := make([]int, 6)
+		for idx, arg := range args {
+			n, ok := arg.(int)
+			if !ok {
+				return nil, builtinErrMsg("Date and time parts must be integers.")
+			}
+			parts[idx] = n
+		}
+		t := time.Date(parts[0], time.Month(parts[1]), parts[2], parts[3], parts[4], parts[5], 0, time.UTC)
+		return &dateTime{t: t}, nil
+	})
+
+	m.define("fromUnix", 1, func(i *Interpreter, args []any) (any, error) {
+		secs, err := i.assertFloat(args[0])
+		if err != nil {
+			return nil, builtinErrMsg("Unix time must be a number of seconds.")
+		}
+		return &dateTime{t: time.Unix(0, int64(secs*float64(time.Second))).UTC()}, nil
+	})
+
+	// parse reads a time in the given layout, or in RFC 3339 format by default.
+	// Times without a zone are in UTC, unless a zone is given as well.
+	m.define("parse", -1, func(i *Interpreter, args []any) (any, error) {
+		if len(args) > 3 {
+			return nil, builtinErrMsg(fmt.Sprintf("Expected at most 3 arguments but got %d.", len(args)))
+		}
+		s, err := stringArg("datetime.parse", "Time", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		layout := time.RFC3339
+		if len(args) > 1 {
+			if layout, err = stringArg("datetime.parse", "Layout", args, 1); err != nil {
+				return nil, err
+			}
+		}
+		loc := time.UTC
+		if len(args) > 2 {
+			zone, err := stringArg("datetime.parse", "Time zone", args, 2)
+			if err != nil {
+				return nil, err
+			}
+			if loc, err = loadZone(zone); err != nil {
+				return nil, err
+			}
+		}
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err != nil {
+			return nil, builtinErrMsg(fmt.Sprintf("Can't parse time '%s' with layout '%s'.", s, layout))
+		}
+		return &dateTime{t: t}, nil
+	})
+
+	// duration parses a duration such as "1h30m" into milliseconds.
+	m.define("duration", 1, func(i *Interpreter, args []any) (any, error) {
+		s, err := stringArg("datetime.duration", "Duration", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, builtinErrMsg(fmt.Sprintf("Invalid duration '%s'.", s))
+		}
+		return durationMillis(d), nil
+	})
+
+	env.define("datetime", m)
+}
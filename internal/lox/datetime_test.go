@@ -0,0 +1,120 @@
+package lox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_datetime(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		code    string
+		input   string
+		want    any
+		wantErr bool
+	}{
+		{
+			desc:  "date_parts",
+			code:  `var d = datetime.date(2024, 2, 29, 13, 45, 30);`,
+			input: `join([d.year(), d.month(), d.day(), d.hour(), d.minute(), d.second(), d.weekday(), d.yearDay()], ",")`,
+			want:  "2024,2,29,13,45,30,Thursday,60",
+		},
+		{
+			desc:    "date_wrong_number_of_parts",
+			input:   `datetime.date(2024, 2)`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:  "format",
+			input: `datetime.date(2024, 3, 1).format(datetime.DATE)`,
+			want:  "2024-03-01",
+		},
+		{
+			desc:  "parse_default_layout",
+			input: `datetime.parse("2024-03-01T10:00:00+02:00").utc().hour()`,
+			want:  8,
+		},
+		{
+			desc:  "parse_with_layout_and_zone",
+			code:  `var d = datetime.parse("2024-07-01 12:00:00", datetime.DATETIME, "Europe/Paris");`,
+			input: `d.zone() + " " + d.utc().format(datetime.TIME)`,
+			want:  "Europe/Paris 10:00:00",
+		},
+		{
+			desc:    "parse_invalid",
+			input:   `datetime.parse("yesterday")`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:  "in_zone",
+			input: `datetime.date(2024, 1, 1).inZone("America/New_York").format(datetime.DATETIME)`,
+			want:  "2023-12-31 19:00:00",
+		},
+		{
+			desc:    "unknown_zone",
+			input:   `datetime.date(2024, 1, 1).inZone("Nowhere/Special")`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:  "add_and_sub",
+			code:  `var d = datetime.date(2024, 1, 1);`,
+			input: `join([d.add(datetime.duration("1h30m")).sub(d), d.add(1.5).sub(d), d.addDate(0, 1, 0).month()], ",")`,
+			want:  "5400000,1.5,2",
+		},
+		{
+			desc:  "compare",
+			code:  `var a = datetime.date(2024, 1, 1); var b = a.add(1);`,
+			input: `a.before(b) and b.after(a) and a.equal(a.inZone("Asia/Tokyo"))`,
+			want:  true,
+		},
+		{
+			desc:  "unix_round_trip",
+			input: `datetime.fromUnix(1700000000.5).unix()`,
+			want:  1700000000.5,
+		},
+		{
+			desc:    "invalid_duration",
+			input:   `datetime.duration("soon")`,
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := runScript(t, tC.code, tC.input)
+			assert.Equal(t, tC.want, got)
+			if tC.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_datetime_clock(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	er := NewLoxErrorReporter()
+	interpreter := NewInterpreter(er)
+	resolver := NewResolver(er, interpreter)
+	rt := NewRuntime(er, interpreter, resolver, WithClock(clock))
+
+	rt.run([]byte(`var started = clock();
+	var today = datetime.now().format(datetime.DATE);
+	var elapsed;
+	setTimeout(fn() { elapsed = nanotime(); }, 1.5);`))
+	assert.False(t, er.HadRuntimeError())
+
+	assert.Equal(t, float64(start.Unix()), interpreter.globals.values["started"])
+	assert.Equal(t, "2025-01-01", interpreter.globals.values["today"])
+	assert.Equal(t, int(1500*time.Microsecond), interpreter.globals.values["elapsed"])
+}
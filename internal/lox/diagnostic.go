@@ -0,0 +1,122 @@
+package lox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrorFormat is how a LoxErrorReporter prints errors.
+type ErrorFormat string
+
+const (
+	// TextFormat prints errors for people, with the source line they are on.
+	TextFormat ErrorFormat = "text"
+	// JSONFormat prints each error as a Diagnostic in a JSON object on its own
+	// line, for editors and CI.
+	JSONFormat ErrorFormat = "json"
+)
+
+// Diagnostic is an error located in the source of a script.
+type Diagnostic struct {
+	// Kind is "syntax" for errors found before the script runs, and
+	// "runtime" for the others.
+	Kind string `json:"kind"`
+	File string `json:"file,omitempty"`
+	// Line and Column are where the error is, from 1. Column and EndColumn,
+	// which is the column after the offending token, are 0 if the source of
+	// the error is not known.
+	Line      int    `json:"line"`
+	Column    int    `json:"column,omitempty"`
+	EndColumn int    `json:"endColumn,omitempty"`
+	Message   string `json:"message"`
+	// Text is the error as printed in the text format, without the source.
+	Text  string  `json:"text"`
+	Trace []Frame `json:"trace,omitempty"`
+}
+
+// sourceFile is the script that errors are reported for.
+type sourceFile struct {
+	name string
+	src  []byte
+}
+
+// locate returns the line of src that tok is on, and the columns where tok
+// starts and ends on it, counted in characters from 1. It reports false if
+// tok doesn't come from src, as happens in the REPL for functions defined by
+// an earlier line.
+func (s *sourceFile) locate(tok token) (string, int, int, bool) {
+	if s == nil || tok.line < 1 || tok.offset < 0 || tok.offset > len(s.src) {
+		return "", 0, 0, false
+	}
+	before := s.src[:tok.offset]
+	if bytes.Count(before, []byte("\n"))+1 != tok.line || !bytes.HasPrefix(s.src[tok.offset:], []byte(tok.lexeme)) {
+		return "", 0, 0, false
+	}
+	start := bytes.LastIndexByte(before, '\n') + 1
+	end := bytes.IndexByte(s.src[start:], '\n')
+	if end < 0 {
+		end = len(s.src)
+	} else {
+		end += start
+	}
+	line := strings.TrimSuffix(string(s.src[start:end]), "\r")
+	col := utf8.RuneCount(s.src[start:tok.offset]) + 1
+	// Tokens that span lines, such as multi-line strings, are underlined up to
+	// the end of their first line.
+	lexeme, _, _ := strings.Cut(tok.lexeme, "\n")
+	width := max(utf8.RuneCountInString(lexeme), 1)
+	return line, col, col + width, true
+}
+
+// ANSI escape codes used to color errors.
+const (
+	colorReset = "\x1b[0m"
+	colorError = "\x1b[1;31m"
+	colorDim   = "\x1b[34m"
+)
+
+// printDiagnostic prints d in format, followed by the source line it is on if
+// it is known.
+func printDiagnostic(w io.Writer, format ErrorFormat, color bool, d Diagnostic, line string) {
+	if format == JSONFormat {
+		json.NewEncoder(w).Encode(d)
+		return
+	}
+	paint := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return code + s + colorReset
+	}
+	fmt.Fprintln(w, paint(colorError, d.Text))
+	if d.Column > 0 {
+		gutter := strings.Repeat(" ", len(fmt.Sprint(d.Line)))
+		loc := fmt.Sprintf("%d:%d", d.Line, d.Column)
+		if d.File != "" {
+			loc = d.File + ":" + loc
+		}
+		// Keep the tabs before the token, so that the underline lines up
+		// with it.
+		var pad strings.Builder
+		for idx, r := range []rune(line) {
+			if idx >= d.Column-1 {
+				break
+			}
+			if r == '\t' {
+				pad.WriteRune('\t')
+			} else {
+				pad.WriteRune(' ')
+			}
+		}
+		underline := "^" + strings.Repeat("~", d.EndColumn-d.Column-1)
+		fmt.Fprintf(w, "%s%s %s\n", gutter, paint(colorDim, "-->"), loc)
+		fmt.Fprintf(w, "%s %s\n", gutter, paint(colorDim, "|"))
+		fmt.Fprintf(w, "%s %s %s\n", paint(colorDim, fmt.Sprint(d.Line)), paint(colorDim, "|"), line)
+		fmt.Fprintf(w, "%s %s %s%s\n", gutter, paint(colorDim, "|"), pad.String(), paint(colorError, underline))
+	}
+	fmt.Fprint(w, RuntimeError{Trace: d.Trace}.Traceback())
+}
@@ -0,0 +1,230 @@
+package lox
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// snippetLine matches the lines of a text diagnostic that show its source.
+var snippetLine = regexp.MustCompile(`^(\s*-->|[\s\d]* \|)`)
+
+// withoutSnippet removes the source lines from the errors in stderr.
+func withoutSnippet(stderr string) string {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(stderr, "\n") {
+		if !snippetLine.MatchString(line) {
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+func Test_diagnostics(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		code       string
+		wantStderr string
+	}{
+		{
+			desc: "underline_token",
+			code: "var greeting = \"hi\";\nprint greeting.length;",
+			wantStderr: `[line 2] Runtime Error at 'length': Only instances have properties.
+ --> main.lox:2:16
+  |
+2 | print greeting.length;
+  |                ^~~~~~
+`,
+		},
+		{
+			desc: "tabs_and_unicode",
+			code: "fn f() {\n\treturn \"héllo\" - 1;\n}\nf();",
+			wantStderr: "[line 2] Runtime Error at '-': Operands must be numbers.\n" +
+				" --> main.lox:2:17\n" +
+				"  |\n" +
+				"2 | \treturn \"héllo\" - 1;\n" +
+				"  | \t               ^\n" +
+				"  at f (main.lox:2)\n" +
+				"  at <script> (main.lox:4)\n",
+		},
+		{
+			desc: "wide_gutter",
+			code: strings.Repeat("\n", 9) + "print nil + 1;",
+			wantStderr: `[line 10] Runtime Error at '+': Operands must be either numbers or strings.
+  --> main.lox:10:11
+   |
+10 | print nil + 1;
+   |           ^
+`,
+		},
+		{
+			desc: "parse_error",
+			code: "var x = 1\nprint x;",
+			wantStderr: `[line 2] Error at 'print': Expect ';' after variable declaration.
+ --> main.lox:2:1
+  |
+2 | print x;
+  | ^~~~~
+`,
+		},
+		{
+			desc: "parse_error_at_end",
+			code: "print (1",
+			wantStderr: `[line 1] Error at end: Expect ')' after expression.
+ --> main.lox:1:9
+  |
+1 | print (1
+  |         ^
+`,
+		},
+		{
+			desc: "scan_error",
+			code: "var a = 1;\nvar b = a @ 2;",
+			wantStderr: `[line 2] Error: unsupported character '@'
+ --> main.lox:2:11
+  |
+2 | var b = a @ 2;
+  |           ^
+`,
+		},
+		{
+			desc: "unterminated_string",
+			code: "var a = 1;\nvar s = \"one\ntwo;",
+			wantStderr: `[line 2] Error: unterminated string
+ --> main.lox:2:9
+  |
+2 | var s = "one
+  |         ^~~~
+`,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+			var stderr strings.Builder
+			er := NewLoxErrorReporter()
+			interpreter := NewInterpreter(er)
+			resolver := NewResolver(er, interpreter)
+			rt := NewRuntime(er, interpreter, resolver, WithStderr(&stderr))
+			interpreter.file = "main.lox"
+
+			rt.run([]byte(tC.code))
+			assert.Equal(t, tC.wantStderr, stderr.String())
+		})
+	}
+}
+
+func Test_diagnostics_json(t *testing.T) {
+	var stderr strings.Builder
+	er := NewLoxErrorReporter()
+	er.SetFormat(JSONFormat)
+	interpreter := NewInterpreter(er)
+	resolver := NewResolver(er, interpreter)
+	rt := NewRuntime(er, interpreter, resolver, WithStderr(&stderr))
+	interpreter.file = "main.lox"
+
+	rt.run([]byte("fn f(x) {\n  return x.name;\n}\nf(1);"))
+	lines := strings.Split(strings.TrimSuffix(stderr.String(), "\n"), "\n")
+	require.Len(t, lines, 1)
+	var d Diagnostic
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &d))
+	assert.Equal(t, Diagnostic{
+		Kind:      "runtime",
+		File:      "main.lox",
+		Line:      2,
+		Column:    12,
+		EndColumn: 16,
+		Message:   "Only instances have properties.",
+		Text:      "[line 2] Runtime Error at 'name': Only instances have properties.",
+		Trace: []Frame{
+			{Function: "f", File: "main.lox", Line: 2},
+			{Function: "<script>", File: "main.lox", Line: 4},
+		},
+	}, d)
+
+	stderr.Reset()
+	rt.run([]byte("print 1 +;"))
+	d = Diagnostic{}
+	require.NoError(t, json.Unmarshal([]byte(stderr.String()), &d))
+	assert.Equal(t, Diagnostic{
+		Kind:      "syntax",
+		File:      "main.lox",
+		Line:      1,
+		Column:    10,
+		EndColumn: 11,
+		Message:   "Expect an expression.",
+		Text:      "[line 1] Error at ';': Expect an expression.",
+	}, d)
+}
+
+func Test_diagnostics_color(t *testing.T) {
+	var stderr strings.Builder
+	er := NewLoxErrorReporter()
+	er.SetColor(true)
+	interpreter := NewInterpreter(er)
+	resolver := NewResolver(er, interpreter)
+	rt := NewRuntime(er, interpreter, resolver, WithStderr(&stderr))
+
+	rt.run([]byte("print -nil;"))
+	assert.Equal(t, "\x1b[1;31m[line 1] Runtime Error at '-': Operand must be a number.\x1b[0m\n"+
+		" \x1b[34m-->\x1b[0m 1:7\n"+
+		"  \x1b[34m|\x1b[0m\n"+
+		"\x1b[34m1\x1b[0m \x1b[34m|\x1b[0m print -nil;\n"+
+		"  \x1b[34m|\x1b[0m       \x1b[1;31m^\x1b[0m\n", stderr.String())
+}
+
+func Test_sourceFile_locate(t *testing.T) {
+	src := &sourceFile{name: "main.lox", src: []byte("var a = 1;\r\nvar b = \"é\" + a;\n")}
+	testCases := []struct {
+		desc     string
+		tok      token
+		wantLine string
+		wantCol  int
+		wantEnd  int
+		wantOk   bool
+	}{
+		{
+			desc:     "first_line",
+			tok:      newToken(IDENTIFIER, "a", nil, 1, 4),
+			wantLine: "var a = 1;",
+			wantCol:  5,
+			wantEnd:  6,
+			wantOk:   true,
+		},
+		{
+			desc:     "after_unicode",
+			tok:      newToken(PLUS, "+", nil, 2, 25),
+			wantLine: `var b = "é" + a;`,
+			wantCol:  13,
+			wantEnd:  14,
+			wantOk:   true,
+		},
+		{
+			desc: "other_source",
+			tok:  newToken(IDENTIFIER, "c", nil, 2, 20),
+		},
+		{
+			desc: "wrong_line",
+			tok:  newToken(IDENTIFIER, "a", nil, 2, 4),
+		},
+		{
+			desc: "past_end",
+			tok:  newToken(IDENTIFIER, "a", nil, 3, 100),
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			line, col, end, ok := src.locate(tC.tok)
+			assert.Equal(t, tC.wantOk, ok)
+			assert.Equal(t, tC.wantLine, line)
+			assert.Equal(t, tC.wantCol, col)
+			assert.Equal(t, tC.wantEnd, end)
+		})
+	}
+}
@@ -3,9 +3,13 @@ package lox
 import (
 	"errors"
 	"fmt"
+	"sync"
 )
 
+// environment may be shared by goroutines spawned from Lox code, so its values
+// are guarded by mu.
 type environment struct {
+	mu        sync.RWMutex
 	values    map[string]any
 	enclosing *environment
 }
@@ -22,17 +26,22 @@ func newGlobalEnvironment() *environment {
 }
 
 func (e *environment) define(varName string, value any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	e.values[varName] = value
 }
 
 func (e *environment) assign(name token, value any) error {
+	e.mu.Lock()
 	if _, ok := e.values[name.lexeme]; !ok {
+		e.mu.Unlock()
 		if e.enclosing != nil {
 			return e.enclosing.assign(name, value)
 		}
 		return NewRuntimeError(name, fmt.Sprintf("Undefined variable '%s'.", name.lexeme))
 	}
 	e.values[name.lexeme] = value
+	e.mu.Unlock()
 	return nil
 }
 
@@ -41,12 +50,14 @@ func (e *environment) assignAt(distance int, name token, value any) error {
 	if err != nil {
 		return err
 	}
-	env.values[name.lexeme] = value
+	env.define(name.lexeme, value)
 	return nil
 }
 
 func (e *environment) get(name token) (any, error) {
+	e.mu.RLock()
 	out, ok := e.values[name.lexeme]
+	e.mu.RUnlock()
 	if ok {
 		return out, nil
 	}
@@ -61,7 +72,9 @@ func (e *environment) getAt(distance int, name string) (any, error) {
 	if err != nil {
 		return nil, err
 	}
+	targetEnv.mu.RLock()
 	out, ok := targetEnv.values[name]
+	targetEnv.mu.RUnlock()
 	if !ok {
 		return nil, fmt.Errorf("incorrect distance %d from resolver: could not find variable '%s'", distance, name)
 	}
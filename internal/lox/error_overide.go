@@ -29,3 +29,27 @@ type loopContinue struct {
 func (lc *loopContinue) Error() string {
 	return fmt.Sprintf("%s on line %d", lc.keyword.lexeme, lc.keyword.line)
 }
+
+type coroutineClose struct{}
+
+func (cc *coroutineClose) Error() string {
+	return "coroutine closed"
+}
+
+// nilChain is returned by an optional link of a chain, such as 'a?.b', whose
+// object is nil. It is caught by the enclosing optionalChainExpr.
+type nilChain struct{}
+
+func (nc *nilChain) Error() string {
+	return "optional chain short-circuited"
+}
+
+// scriptExit is returned by the exit native function. It unwinds the whole
+// script, and its code becomes the exit status of the Runtime.
+type scriptExit struct {
+	code int
+}
+
+func (se *scriptExit) Error() string {
+	return fmt.Sprintf("exit status %d", se.code)
+}
@@ -2,35 +2,77 @@ package lox
 
 import (
 	"fmt"
+	"io"
+	"os"
 )
 
 type ErrorReporter interface {
-	report(line int, where, msg string)
+	report(tok token, where, msg string)
 	HadError() bool
 	HadRuntimeError() bool
 	ResetError()
 	ResetRuntimeError()
-	ScanError(line int, msg string)
+	ScanError(tok token, msg string)
 	ParseError(token token, msg string) ParseError
 	RuntimeError(e RuntimeError)
 }
 
 type LoxErrorReporter struct {
+	// out is where errors are printed, which is os.Stderr by default.
+	out    io.Writer
+	format ErrorFormat
+	color  bool
+	// source is the script being run, which errors are located in.
+	source          *sourceFile
 	hadError        bool
 	hadRuntimeError bool
 }
 
 func NewLoxErrorReporter() *LoxErrorReporter {
-	return &LoxErrorReporter{}
+	return &LoxErrorReporter{out: os.Stderr, format: TextFormat}
 }
 
-func (l *LoxErrorReporter) report(line int, where, msg string) {
-	fmt.Printf("[line %d] Error%s: %s\n", line, where, msg)
+// SetOutput sets the writer that errors are printed to.
+func (l *LoxErrorReporter) SetOutput(w io.Writer) {
+	l.out = w
+}
+
+// SetFormat sets how errors are printed, which is TextFormat by default.
+func (l *LoxErrorReporter) SetFormat(format ErrorFormat) {
+	l.format = format
+}
+
+// SetColor sets whether errors printed in the text format are colored with
+// ANSI escape codes.
+func (l *LoxErrorReporter) SetColor(color bool) {
+	l.color = color
+}
+
+// setSource sets the script that the next errors are reported for.
+func (l *LoxErrorReporter) setSource(name string, src []byte) {
+	l.source = &sourceFile{name: name, src: src}
+}
+
+// diagnose prints the error msg raised at tok, whose text format is text.
+func (l *LoxErrorReporter) diagnose(kind string, tok token, msg, text string, trace []Frame) {
+	d := Diagnostic{Kind: kind, Line: tok.line, Message: msg, Text: text, Trace: trace}
+	if l.source != nil {
+		d.File = l.source.name
+	}
+	line, col, endCol, ok := l.source.locate(tok)
+	if ok {
+		d.Column, d.EndColumn = col, endCol
+	}
+	printDiagnostic(l.out, l.format, l.color, d, line)
+}
+
+func (l *LoxErrorReporter) report(tok token, where, msg string) {
+	l.diagnose("syntax", tok, msg, fmt.Sprintf("[line %d] Error%s: %s", tok.line, where, msg), nil)
 	l.hadError = true
 }
 
-func (l *LoxErrorReporter) ScanError(line int, msg string) {
-	l.report(line, "", msg)
+func (l *LoxErrorReporter) ScanError(tok token, msg string) {
+	l.report(tok, "", msg)
 }
 
 func (l *LoxErrorReporter) HadError() bool {
@@ -44,9 +86,9 @@ func (l *LoxErrorReporter) ResetError() {
 func (l *LoxErrorReporter) ParseError(token token, msg string) ParseError {
 	switch token.tokenType {
 	case EOF:
-		l.report(token.line, " at end", msg)
+		l.report(token, " at end", msg)
 	default:
-		l.report(token.line, fmt.Sprintf(" at '%s'", token.lexeme), msg)
+		l.report(token, fmt.Sprintf(" at '%s'", token.lexeme), msg)
 	}
 
 	return ParseError{Token: token, Msg: msg}
@@ -73,10 +115,18 @@ func (e ParseError) Error() string {
 type RuntimeError struct {
 	Token token
 	Msg   string
+	// Trace holds the calls that led to the error, from the innermost. It is
+	// nil if the error was raised at the top level of the script.
+	Trace []Frame
 }
 
 func NewRuntimeError(token token, msg string) RuntimeError {
-	return RuntimeError{token, msg}
+	return RuntimeError{Token: token, Msg: msg}
+}
+
+// Line returns the line of the token where the error was raised.
+func (e RuntimeError) Line() int {
+	return e.Token.line
 }
 
 func (e RuntimeError) Error() string {
@@ -85,7 +135,7 @@ func (e RuntimeError) Error() string {
 
 func (l *LoxErrorReporter) RuntimeError(err RuntimeError) {
 	l.hadRuntimeError = true
-	fmt.Println(err.Error())
+	l.diagnose("runtime", err.Token, err.Msg, err.Error(), err.Trace)
 }
 
 func (l *LoxErrorReporter) HadRuntimeError() bool {
@@ -0,0 +1,293 @@
+package lox
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for the event loop. It can be replaced to make
+// timers deterministic, for example with a clock whose Sleep only advances Now.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// eventLoop schedules callbacks and timers to run one at a time. Callbacks
+// may be added from any goroutine, but are all run by whoever drains the loop.
+type eventLoop struct {
+	clock Clock
+	// started is when the loop was created, which nanotime counts from.
+	started time.Time
+	mu      sync.Mutex
+	queue   []func() error
+	timers  timerHeap
+	active  map[int]*timer
+	nextID  int
+	// seq keeps timers with the same deadline in the order they were scheduled.
+	seq int
+}
+
+type timer struct {
+	id       int
+	seq      int
+	deadline time.Time
+	interval time.Duration
+	fn       func() error
+}
+
+func newEventLoop(clock Clock) *eventLoop {
+	return &eventLoop{
+		clock:   clock,
+		started: clock.Now(),
+		queue:   make([]func() error, 0),
+		timers:  make(timerHeap, 0),
+		active:  make(map[int]*timer),
+	}
+}
+
+// enqueue adds a callback to run as soon as possible.
+func (l *eventLoop) enqueue(fn func() error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.queue = append(l.queue, fn)
+}
+
+// schedule adds a callback to run after delay, and then every interval if
+// interval is positive. It returns the timer id to use with cancel.
+func (l *eventLoop) schedule(delay, interval time.Duration, fn func() error) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nextID++
+	t := &timer{
+		id:       l.nextID,
+		deadline: l.clock.Now().Add(delay),
+		interval: interval,
+		fn:       fn,
+	}
+	l.push(t)
+	l.active[t.id] = t
+	return t.id
+}
+
+func (l *eventLoop) push(t *timer) {
+	l.seq++
+	t.seq = l.seq
+	heap.Push(&l.timers, t)
+}
+
+// cancel stops the timer with the given id. Unknown ids are ignored.
+func (l *eventLoop) cancel(id int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.active, id)
+}
+
+// clear drops the callbacks and timers that have not run yet.
+func (l *eventLoop) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.queue = l.queue[:0]
+	l.timers = l.timers[:0]
+	clear(l.active)
+}
+
+// next returns the next callback to run, waiting for the earliest timer if no
+// callback is ready. It returns false once there is nothing left to run, or
+// once done is closed while waiting.
+func (l *eventLoop) next(done <-chan struct{}) (func() error, bool) {
+	for {
+		l.mu.Lock()
+		if len(l.queue) > 0 {
+			fn := l.queue[0]
+			l.queue = l.queue[1:]
+			l.mu.Unlock()
+			return fn, true
+		}
+		for l.timers.Len() > 0 && l.active[l.timers[0].id] == nil {
+			heap.Pop(&l.timers)
+		}
+		if l.timers.Len() == 0 {
+			l.mu.Unlock()
+			return nil, false
+		}
+		t := l.timers[0]
+		now := l.clock.Now()
+		if t.deadline.After(now) {
+			l.mu.Unlock()
+			if !l.sleep(t.deadline.Sub(now), done) {
+				return nil, false
+			}
+			continue
+		}
+		heap.Pop(&l.timers)
+		if t.interval > 0 {
+			t.deadline = t.deadline.Add(t.interval)
+			l.push(t)
+		} else {
+			delete(l.active, t.id)
+		}
+		l.mu.Unlock()
+		return t.fn, true
+	}
+}
+
+// sleep waits for d, and reports false if done is closed first. Only the system
+// clock can be interrupted, as other clocks may not sleep in real time.
+func (l *eventLoop) sleep(d time.Duration, done <-chan struct{}) bool {
+	if _, ok := l.clock.(systemClock); !ok || done == nil {
+		l.clock.Sleep(d)
+		return true
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+type timerHeap []*timer
+
+func (h timerHeap) Len() int { return len(h) }
+
+func (h timerHeap) Less(i, j int) bool {
+	if h[i].deadline.Equal(h[j].deadline) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].deadline.Before(h[j].deadline)
+}
+
+func (h timerHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *timerHeap) Push(x any) { *h = append(*h, x.(*timer)) }
+
+func (h *timerHeap) Pop() any {
+	old := *h
+	n := len(old)
+	t := old[n-1]
+	*h = old[:n-1]
+	return t
+}
+
+// millis converts a Lox number of milliseconds to a duration.
+func millis(val any) (time.Duration, error) {
+	switch v := val.(type) {
+	case int:
+		return time.Duration(v) * time.Millisecond, nil
+	case float64:
+		return time.Duration(v * float64(time.Millisecond)), nil
+	default:
+		return 0, builtinErrMsg("Delay must be a number of milliseconds.")
+	}
+}
+
+// callback returns a loop callback that calls fn with no arguments on a fork
+// of the interpreter, as if from the call site of the native scheduling it.
+func callback(i *Interpreter, fn callable) func() error {
+	site := i.site
+	return func() error {
+		_, err := i.fork().call(site, fn, []any{})
+		return err
+	}
+}
+
+func defineTimerFns(env *environment) {
+	setTimer := func(name string, repeat bool) builtinFn {
+		return newBuiltinFn(name, 2, func(i *Interpreter, args []any) (any, error) {
+			fn, ok := args[0].(callable)
+			if !ok || fn.arity() != 0 {
+				return nil, builtinErrMsg(fmt.Sprintf("Can only call '%s' with a function that takes no arguments.", name))
+			}
+			delay, err := millis(args[1])
+			if err != nil {
+				return nil, err
+			}
+			var interval time.Duration
+			if repeat {
+				interval = max(delay, time.Millisecond)
+			}
+			return i.loop.schedule(delay, interval, callback(i, fn)), nil
+		})
+	}
+	clearTimer := func(name string) builtinFn {
+		return newBuiltinFn(name, 1, func(i *Interpreter, args []any) (any, error) {
+			id, ok := args[0].(int)
+			if !ok {
+				return nil, builtinErrMsg("Timer id must be an integer.")
+			}
+			i.loop.cancel(id)
+			return nil, nil
+		})
+	}
+	env.define("setTimeout", setTimer("setTimeout", false))
+	env.define("setInterval", setTimer("setInterval", true))
+	env.define("clearTimeout", clearTimer("clearTimeout"))
+	env.define("clearInterval", clearTimer("clearInterval"))
+
+	env.define("sleep", newBuiltinFn("sleep", 1, func(i *Interpreter, args []any) (any, error) {
+		delay, err := millis(args[0])
+		if err != nil {
+			return nil, err
+		}
+		fut := newFuture(i.loop)
+		i.loop.schedule(delay, 0, func() error {
+			fut.settle(nil, nil)
+			return nil
+		})
+		return fut, nil
+	}))
+
+	// gather returns a future that resolves to an array of the results of the
+	// given futures, or fails with the first of their errors.
+	env.define("gather", newBuiltinFn("gather", 1, func(i *Interpreter, args []any) (any, error) {
+		arr, ok := args[0].(*array)
+		if !ok {
+			return nil, builtinErrMsg("Can only call 'gather' on an array of futures.")
+		}
+		futures := make([]*future, arr.Len())
+		for idx := range futures {
+			fut, ok := arr.Get(idx).(*future)
+			if !ok {
+				return nil, builtinErrMsg("Can only call 'gather' on an array of futures.")
+			}
+			futures[idx] = fut
+		}
+		out := newFuture(i.loop)
+		results := make([]any, len(futures))
+		remaining := len(futures)
+		if remaining == 0 {
+			out.settle(newArray(), nil)
+		}
+		for idx, fut := range futures {
+			fut.onSettle(func(val any, err error) error {
+				if err != nil {
+					out.settle(nil, err)
+					return nil
+				}
+				results[idx] = val
+				remaining--
+				if remaining == 0 {
+					res := newArray()
+					res.Append(results...)
+					out.settle(res, nil)
+				}
+				return nil
+			})
+		}
+		return out, nil
+	}))
+}
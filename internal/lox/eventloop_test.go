@@ -0,0 +1,160 @@
+package lox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock advances time only when the event loop sleeps, so timers fire
+// immediately and in a deterministic order.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func Test_eventLoop(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		code        string
+		wantEnv     map[string]any
+		wantElapsed time.Duration
+	}{
+		{
+			desc: "timeouts_fire_in_deadline_order",
+			code: `var log = "";
+			setTimeout(fn() { log = log + "c"; }, 30);
+			setTimeout(fn() { log = log + "a"; }, 10);
+			setTimeout(fn() { log = log + "b"; }, 20);`,
+			wantEnv:     map[string]any{"log": "abc"},
+			wantElapsed: 30 * time.Millisecond,
+		},
+		{
+			desc: "same_deadline_keeps_schedule_order",
+			code: `var log = "";
+			setTimeout(fn() { log = log + "a"; }, 10);
+			setTimeout(fn() { log = log + "b"; }, 10);`,
+			wantEnv:     map[string]any{"log": "ab"},
+			wantElapsed: 10 * time.Millisecond,
+		},
+		{
+			desc: "clear_timeout",
+			code: `var fired = false;
+			var id = setTimeout(fn() { fired = true; }, 10);
+			clearTimeout(id);`,
+			wantEnv:     map[string]any{"fired": false},
+			wantElapsed: 0,
+		},
+		{
+			desc: "interval_until_cleared",
+			code: `var ticks = 0;
+			var id;
+			id = setInterval(fn() {
+				ticks = ticks + 1;
+				if ticks == 3 {
+					clearInterval(id);
+				}
+			}, 100);`,
+			wantEnv:     map[string]any{"ticks": 3},
+			wantElapsed: 300 * time.Millisecond,
+		},
+		{
+			desc: "await_sleep",
+			code: `var result;
+			async fn delayed(x) {
+				await sleep(50);
+				return x * 2;
+			}
+			delayed(21).then(fn(v) { result = v; });`,
+			wantEnv:     map[string]any{"result": 42},
+			wantElapsed: 50 * time.Millisecond,
+		},
+		{
+			desc: "async_runs_until_first_await",
+			code: `var log = "";
+			async fn task() {
+				log = log + "a";
+				await sleep(0);
+				log = log + "c";
+			}
+			task();
+			log = log + "b";`,
+			wantEnv:     map[string]any{"log": "abc"},
+			wantElapsed: 0,
+		},
+		{
+			desc: "gather_runs_concurrently",
+			code: `var first;
+			var second;
+			async fn wait(x, ms) {
+				await sleep(ms);
+				return x;
+			}
+			async fn main() {
+				var all = await gather([wait("a", 30), wait("b", 20)]);
+				first = all[0];
+				second = all[1];
+			}
+			main();`,
+			wantEnv:     map[string]any{"first": "a", "second": "b"},
+			wantElapsed: 30 * time.Millisecond,
+		},
+		{
+			desc: "await_non_future",
+			code: `var result;
+			async fn id(x) {
+				return await x;
+			}
+			id(7).then(fn(v) { result = v; });`,
+			wantEnv:     map[string]any{"result": 7},
+			wantElapsed: 0,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+			start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			clock := &fakeClock{now: start}
+
+			er := NewLoxErrorReporter()
+			interpreter := NewInterpreter(er)
+			resolver := NewResolver(er, interpreter)
+			rt := NewRuntime(er, interpreter, resolver, WithClock(clock))
+
+			rt.run([]byte(tC.code))
+			assert.False(t, er.HadError())
+			assert.False(t, er.HadRuntimeError())
+
+			for k, v := range tC.wantEnv {
+				val, exists := interpreter.globals.values[k]
+				assert.True(t, exists)
+				assert.Equal(t, v, val)
+			}
+			assert.Equal(t, tC.wantElapsed, clock.now.Sub(start))
+		})
+	}
+}
+
+func Test_eventLoop_asyncError(t *testing.T) {
+	clock := &fakeClock{}
+	er := NewLoxErrorReporter()
+	interpreter := NewInterpreter(er)
+	resolver := NewResolver(er, interpreter)
+	rt := NewRuntime(er, interpreter, resolver, WithClock(clock))
+
+	rt.run([]byte(`async fn broken() {
+		await sleep(1);
+		return 1 - "one";
+	}
+	broken();`))
+	assert.True(t, er.HadRuntimeError())
+}
@@ -9,15 +9,20 @@ type expr interface {
 type exprVisitor interface {
 	visitArrayExpr(e arrayExpr) (any, error)
 	visitAssignExpr(e assignExpr) (any, error)
+	visitAwaitExpr(e awaitExpr) (any, error)
 	visitBinaryExpr(e binaryExpr) (any, error)
 	visitCallExpr(e callExpr) (any, error)
 	visitFunctionExpr(e functionExpr) (any, error)
 	visitGetExpr(e getExpr) (any, error)
 	visitGroupingExpr(e groupingExpr) (any, error)
 	visitIndexExpr(e indexExpr) (any, error)
+	visitIndexSetExpr(e indexSetExpr) (any, error)
 	visitLiteralExpr(e literalExpr) (any, error)
 	visitLogicalExpr(e logicalExpr) (any, error)
+	visitMapExpr(e mapExpr) (any, error)
+	visitOptionalChainExpr(e optionalChainExpr) (any, error)
 	visitSetExpr(e setExpr) (any, error)
+	visitSpawnExpr(e spawnExpr) (any, error)
 	visitSuperExpr(e superExpr) (any, error)
 	visitTernaryExpr(e ternaryExpr) (any, error)
 	visitThisExpr(e thisExpr) (any, error)
@@ -26,7 +31,8 @@ type exprVisitor interface {
 }
 
 type arrayExpr struct {
-	value []expr
+	bracket token
+	value   []expr
 }
 
 func (e arrayExpr) accept(v exprVisitor) (any, error) {
@@ -42,6 +48,15 @@ func (e assignExpr) accept(v exprVisitor) (any, error) {
 	return v.visitAssignExpr(e)
 }
 
+type awaitExpr struct {
+	keyword token
+	value   expr
+}
+
+func (e awaitExpr) accept(v exprVisitor) (any, error) {
+	return v.visitAwaitExpr(e)
+}
+
 type binaryExpr struct {
 	left     expr
 	operator token
@@ -63,8 +78,10 @@ func (e callExpr) accept(v exprVisitor) (any, error) {
 }
 
 type functionExpr struct {
-	params []token
-	body   []stmt
+	params      []token
+	body        []stmt
+	isGenerator bool
+	isAsync     bool
 }
 
 func (e functionExpr) accept(v exprVisitor) (any, error) {
@@ -72,8 +89,9 @@ func (e functionExpr) accept(v exprVisitor) (any, error) {
 }
 
 type getExpr struct {
-	object expr
-	name   token
+	object   expr
+	name     token
+	optional bool
 }
 
 func (e getExpr) accept(v exprVisitor) (any, error) {
@@ -89,13 +107,25 @@ func (e groupingExpr) accept(v exprVisitor) (any, error) {
 }
 
 type indexExpr struct {
+	callee   expr
+	bracket  token
+	index    expr
+	optional bool
+}
+
+func (e indexExpr) accept(v exprVisitor) (any, error) {
+	return v.visitIndexExpr(e)
+}
+
+type indexSetExpr struct {
 	callee  expr
 	bracket token
 	index   expr
+	value   expr
 }
 
-func (e indexExpr) accept(v exprVisitor) (any, error) {
-	return v.visitIndexExpr(e)
+func (e indexSetExpr) accept(v exprVisitor) (any, error) {
+	return v.visitIndexSetExpr(e)
 }
 
 type literalExpr struct {
@@ -116,6 +146,24 @@ func (e logicalExpr) accept(v exprVisitor) (any, error) {
 	return v.visitLogicalExpr(e)
 }
 
+type mapExpr struct {
+	brace  token
+	keys   []expr
+	values []expr
+}
+
+func (e mapExpr) accept(v exprVisitor) (any, error) {
+	return v.visitMapExpr(e)
+}
+
+type optionalChainExpr struct {
+	chain expr
+}
+
+func (e optionalChainExpr) accept(v exprVisitor) (any, error) {
+	return v.visitOptionalChainExpr(e)
+}
+
 type setExpr struct {
 	object expr
 	name   token
@@ -126,6 +174,15 @@ func (e setExpr) accept(v exprVisitor) (any, error) {
 	return v.visitSetExpr(e)
 }
 
+type spawnExpr struct {
+	keyword token
+	call    callExpr
+}
+
+func (e spawnExpr) accept(v exprVisitor) (any, error) {
+	return v.visitSpawnExpr(e)
+}
+
 type superExpr struct {
 	keyword token
 	method  token
@@ -0,0 +1,330 @@
+package lox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// formatSpec is a parsed replacement field spec, the part after the colon in
+// '{:>8.2f}'. Its syntax is
+//
+//	[[fill]align][sign][#][0][width][.precision][verb]
+//
+// where align is one of '<', '>' or '^', sign is one of '+', '-' or ' ', '#'
+// adds a base prefix to integers, and verb is one of 'd', 'x', 'X', 'o', 'b',
+// 'f', 'e', 'g', '%' or 's'.
+type formatSpec struct {
+	fill      rune
+	align     byte
+	sign      byte
+	alt       bool
+	zero      bool
+	width     int
+	precision int
+	verb      byte
+}
+
+// maxFormatWidth bounds the width and precision of a format spec, so that a
+// spec can't pad a value to a string too large to allocate.
+const maxFormatWidth = 1 << 16
+
+func parseFormatSpec(s string) (formatSpec, error) {
+	spec := formatSpec{fill: ' ', sign: '-', precision: -1}
+	invalid := builtinErrMsg(fmt.Sprintf("Invalid format spec '%s'.", s))
+	rest := s
+
+	isAlign := func(b byte) bool { return b == '<' || b == '>' || b == '^' }
+	if r, size := utf8.DecodeRuneInString(rest); size > 0 && len(rest) > size && isAlign(rest[size]) {
+		spec.fill, spec.align = r, rest[size]
+		rest = rest[size+1:]
+	} else if len(rest) > 0 && isAlign(rest[0]) {
+		spec.align = rest[0]
+		rest = rest[1:]
+	}
+	if len(rest) > 0 && strings.IndexByte("+- ", rest[0]) >= 0 {
+		spec.sign = rest[0]
+		rest = rest[1:]
+	}
+	if len(rest) > 0 && rest[0] == '#' {
+		spec.alt = true
+		rest = rest[1:]
+	}
+	if len(rest) > 0 && rest[0] == '0' {
+		spec.zero = true
+		rest = rest[1:]
+	}
+	var tooLarge bool
+	digits := func() (int, bool) {
+		end := 0
+		for end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+			end++
+		}
+		n, err := strconv.Atoi(rest[:end])
+		if end > 0 && (err != nil || n > maxFormatWidth) {
+			tooLarge = true
+		}
+		rest = rest[end:]
+		return n, err == nil
+	}
+	if n, ok := digits(); ok {
+		spec.width = n
+	}
+	if len(rest) > 0 && rest[0] == '.' {
+		rest = rest[1:]
+		n, ok := digits()
+		if !ok && !tooLarge {
+			return spec, invalid
+		}
+		spec.precision = n
+	}
+	if tooLarge {
+		return spec, invalid
+	}
+	if len(rest) > 0 {
+		spec.verb = rest[0]
+		rest = rest[1:]
+		if strings.IndexByte("dxXobfeg%s", spec.verb) < 0 {
+			return spec, invalid
+		}
+	}
+	if rest != "" {
+		return spec, invalid
+	}
+	return spec, nil
+}
+
+// formatNumber returns the sign and the digits of a number formatted with spec.
+func formatNumber(val any, spec formatSpec) (string, string, error) {
+	verb := spec.verb
+	n, isInt := val.(int)
+	f, isFloat := val.(float64)
+	if !isInt && !isFloat {
+		return "", "", builtinErrMsg(fmt.Sprintf("Format '%c' requires a number but got '%v'.", verb, val))
+	}
+	if verb == 0 {
+		if isInt {
+			verb = 'd'
+		} else if spec.precision >= 0 {
+			verb = 'g'
+		}
+	}
+
+	var neg bool
+	var digits string
+	switch verb {
+	case 'd', 'x', 'X', 'o', 'b':
+		if !isInt {
+			return "", "", builtinErrMsg(fmt.Sprintf("Format '%c' requires an integer but got '%v'.", verb, val))
+		}
+		neg = n < 0
+		u := uint64(n)
+		if neg {
+			u = -u
+		}
+		base := map[byte]int{'d': 10, 'x': 16, 'X': 16, 'o': 8, 'b': 2}[verb]
+		digits = strconv.FormatUint(u, base)
+		if verb == 'X' {
+			digits = strings.ToUpper(digits)
+		}
+		if spec.alt && verb != 'd' {
+			digits = "0" + string(verb) + digits
+		}
+	default:
+		if isInt {
+			f = float64(n)
+		}
+		neg = f < 0
+		if neg {
+			f = -f
+		}
+		switch verb {
+		case 0:
+			digits = fmt.Sprint(f)
+		case '%':
+			digits = strconv.FormatFloat(f*100, 'f', precisionOr(spec.precision, 6), 64) + "%"
+		case 'g':
+			digits = strconv.FormatFloat(f, 'g', precisionOr(spec.precision, -1), 64)
+		default:
+			digits = strconv.FormatFloat(f, verb, precisionOr(spec.precision, 6), 64)
+		}
+	}
+
+	sign := ""
+	switch {
+	case neg:
+		sign = "-"
+	case spec.sign == '+':
+		sign = "+"
+	case spec.sign == ' ':
+		sign = " "
+	}
+	return sign, digits, nil
+}
+
+func precisionOr(precision, fallback int) int {
+	if precision < 0 {
+		return fallback
+	}
+	return precision
+}
+
+// formatValue formats val as the replacement field with spec. Numbers are
+// aligned right and other values left, unless the spec says otherwise.
+func formatValue(val any, spec formatSpec) (string, error) {
+	var s string
+	align := spec.align
+	if spec.verb == 's' || (spec.verb == 0 && !isNumber(val)) {
+		s = fmt.Sprint(val)
+		if spec.precision >= 0 && utf8.RuneCountInString(s) > spec.precision {
+			s = string([]rune(s)[:spec.precision])
+		}
+		if align == 0 {
+			align = '<'
+		}
+	} else {
+		sign, digits, err := formatNumber(val, spec)
+		if err != nil {
+			return "", err
+		}
+		if spec.zero && spec.align == 0 {
+			// Pad with zeros between the sign and the digits.
+			prefix := ""
+			if spec.alt && len(digits) > 2 && digits[0] == '0' && strings.IndexByte("xXob", digits[1]) >= 0 {
+				prefix, digits = digits[:2], digits[2:]
+			}
+			pad := spec.width - len(sign) - len(prefix) - utf8.RuneCountInString(digits)
+			if pad > 0 {
+				digits = strings.Repeat("0", pad) + digits
+			}
+			return sign + prefix + digits, nil
+		}
+		s = sign + digits
+		if align == 0 {
+			align = '>'
+		}
+	}
+
+	pad := spec.width - utf8.RuneCountInString(s)
+	if pad <= 0 {
+		return s, nil
+	}
+	fill := string(spec.fill)
+	switch align {
+	case '<':
+		return s + strings.Repeat(fill, pad), nil
+	case '^':
+		return strings.Repeat(fill, pad/2) + s + strings.Repeat(fill, pad-pad/2), nil
+	default:
+		return strings.Repeat(fill, pad) + s, nil
+	}
+}
+
+func isNumber(val any) bool {
+	switch val.(type) {
+	case int, float64:
+		return true
+	}
+	return false
+}
+
+// sprintf replaces the fields of layout, such as '{}', '{1}' or '{:>8.2f}',
+// with the formatted args. Fields are numbered automatically unless they have
+// an index, and '{{' and '}}' stand for literal braces.
+func sprintf(layout string, args []any) (string, error) {
+	var sb strings.Builder
+	next := 0
+	manual := false
+	for idx := 0; idx < len(layout); idx++ {
+		c := layout[idx]
+		if c == '}' {
+			if idx+1 < len(layout) && layout[idx+1] == '}' {
+				sb.WriteByte('}')
+				idx++
+				continue
+			}
+			return "", builtinErrMsg("Single '}' in format string.")
+		}
+		if c != '{' {
+			sb.WriteByte(c)
+			continue
+		}
+		if idx+1 < len(layout) && layout[idx+1] == '{' {
+			sb.WriteByte('{')
+			idx++
+			continue
+		}
+		end := strings.IndexByte(layout[idx:], '}')
+		if end < 0 {
+			return "", builtinErrMsg("Unclosed '{' in format string.")
+		}
+		field := layout[idx+1 : idx+end]
+		idx += end
+
+		name, specStr, _ := strings.Cut(field, ":")
+		argIdx := next
+		if name == "" {
+			if manual {
+				return "", builtinErrMsg("Can't mix automatic and manual field numbering.")
+			}
+			next++
+		} else {
+			n, err := strconv.Atoi(name)
+			if err != nil || n < 0 {
+				return "", builtinErrMsg(fmt.Sprintf("Invalid field '{%s}' in format string.", field))
+			}
+			if next > 0 {
+				return "", builtinErrMsg("Can't mix automatic and manual field numbering.")
+			}
+			manual = true
+			argIdx = n
+		}
+		if argIdx >= len(args) {
+			return "", builtinErrMsg(fmt.Sprintf("Format string refers to argument %d but got %d arguments.", argIdx, len(args)))
+		}
+
+		spec, err := parseFormatSpec(specStr)
+		if err != nil {
+			return "", err
+		}
+		s, err := formatValue(args[argIdx], spec)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(s)
+	}
+	return sb.String(), nil
+}
+
+func defineFormatFns(env *environment) {
+	// format takes a format string followed by the values of its fields.
+	env.define("format", newBuiltinFn("format", -1, func(i *Interpreter, args []any) (any, error) {
+		layout, err := stringArg("format", "Format", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return sprintf(layout, args[1:])
+	}))
+
+	// printf prints like format, without adding a newline.
+	env.define("printf", newBuiltinFn("printf", -1, func(i *Interpreter, args []any) (any, error) {
+		layout, err := stringArg("printf", "Format", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		s, err := sprintf(layout, args[1:])
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprint(i.stdout, s)
+		return nil, nil
+	}))
+
+	// println prints its arguments separated by spaces and followed by a
+	// newline.
+	env.define("println", newBuiltinFn("println", anyArity, func(i *Interpreter, args []any) (any, error) {
+		fmt.Fprintln(i.stdout, args...)
+		return nil, nil
+	}))
+}
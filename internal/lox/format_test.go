@@ -0,0 +1,201 @@
+package lox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sprintf(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		layout  string
+		args    []any
+		want    string
+		wantErr bool
+	}{
+		{
+			desc:   "default_fields",
+			layout: "{} has {} items costing {}",
+			args:   []any{"cart", 3, 9.5},
+			want:   "cart has 3 items costing 9.5",
+		},
+		{
+			desc:   "manual_fields",
+			layout: "{1} {0} {1}",
+			args:   []any{"a", "b"},
+			want:   "b a b",
+		},
+		{
+			desc:   "escaped_braces",
+			layout: "{{{}}}",
+			args:   []any{1},
+			want:   "{1}",
+		},
+		{
+			desc:   "width_precision_alignment",
+			layout: "[{:>8.2f}][{:<6}][{:^7}][{:8}]",
+			args:   []any{3.14159, "ab", "mid", 42},
+			want:   "[    3.14][ab    ][  mid  ][      42]",
+		},
+		{
+			desc:   "fill",
+			layout: "{:*^9}|{:->5}|{:0<4}",
+			args:   []any{"x", 1, 7},
+			want:   "****x****|----1|7000",
+		},
+		{
+			desc:   "integer_bases",
+			layout: "{:x} {:X} {:#x} {:#X} {:b} {:#b} {:o} {:#o}",
+			args:   []any{255, 255, 255, 255, 5, 5, 8, 8},
+			want:   "ff FF 0xff 0XFF 101 0b101 10 0o10",
+		},
+		{
+			desc:   "negative_hex",
+			layout: "{:x}",
+			args:   []any{-255},
+			want:   "-ff",
+		},
+		{
+			desc:   "sign_and_zero_padding",
+			layout: "{:+d} {: d} {:05d} {:08.3f} {:#010x}",
+			args:   []any{7, 7, -42, -3.14159, 255},
+			want:   "+7  7 -0042 -003.142 0x000000ff",
+		},
+		{
+			desc:   "float_verbs",
+			layout: "{:.3} {:e} {:.1%} {:f}",
+			args:   []any{3.14159, 1234.5, 0.1234, 2},
+			want:   "3.14 1.234500e+03 12.3% 2.000000",
+		},
+		{
+			desc:   "string_precision_truncates",
+			layout: "{:.2s}|{:s}",
+			args:   []any{"hello", 1.5},
+			want:   "he|1.5",
+		},
+		{
+			desc:    "integer_verb_with_float",
+			layout:  "{:d}",
+			args:    []any{1.5},
+			wantErr: true,
+		},
+		{
+			desc:    "number_verb_with_string",
+			layout:  "{:.2f}",
+			args:    []any{"x"},
+			wantErr: true,
+		},
+		{
+			desc:    "missing_argument",
+			layout:  "{} {}",
+			args:    []any{1},
+			wantErr: true,
+		},
+		{
+			desc:    "mixed_numbering",
+			layout:  "{} {0}",
+			args:    []any{1},
+			wantErr: true,
+		},
+		{
+			desc:    "unclosed_field",
+			layout:  "{:d",
+			args:    []any{1},
+			wantErr: true,
+		},
+		{
+			desc:    "single_closing_brace",
+			layout:  "}",
+			wantErr: true,
+		},
+		{
+			desc:    "huge_width",
+			layout:  "{:>99999999999999}",
+			args:    []any{1},
+			wantErr: true,
+		},
+		{
+			desc:    "width_overflowing_int",
+			layout:  "{:99999999999999999999999}",
+			args:    []any{1},
+			wantErr: true,
+		},
+		{
+			desc:    "huge_precision",
+			layout:  "{:.99999999f}",
+			args:    []any{1.5},
+			wantErr: true,
+		},
+		{
+			desc:   "largest_width",
+			layout: "{:65536}",
+			args:   []any{"x"},
+			want:   "x" + strings.Repeat(" ", 65535),
+		},
+		{
+			desc:    "invalid_spec",
+			layout:  "{:8.2q}",
+			args:    []any{1},
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+			got, err := sprintf(tC.layout, tC.args)
+			if tC.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func Test_formatFn(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		input   string
+		want    any
+		wantErr bool
+	}{
+		{
+			desc:  "format",
+			input: `format("{:>6.1f}|{}", 2.25, "x")`,
+			want:  "   2.2|x",
+		},
+		{
+			desc:  "format_without_fields",
+			input: `format("plain")`,
+			want:  "plain",
+		},
+		{
+			desc:    "format_huge_width",
+			input:   `format("{:>99999999999999}", 1)`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:    "format_needs_string",
+			input:   `format(1)`,
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := runScript(t, "", tC.input)
+			assert.Equal(t, tC.want, got)
+			if tC.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package lox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FS is the filesystem used by the fs module. It extends the read-only io/fs
+// interfaces with the operations needed to change files, so that embedders can
+// substitute an in-memory filesystem for the OS one, for example in tests.
+type FS interface {
+	fs.StatFS
+	fs.ReadDirFS
+	// OpenFile opens the named file with the given os.O_* flags, creating it
+	// with perm if os.O_CREATE is set.
+	OpenFile(name string, flag int, perm fs.FileMode) (File, error)
+	MkdirAll(name string, perm fs.FileMode) error
+	Remove(name string) error
+}
+
+// File is a file opened with FS.OpenFile.
+type File interface {
+	fs.File
+	io.Writer
+}
+
+// osFS is the FS backed by the filesystem of the OS. Names are passed to the os
+// package as they are, so they may be absolute or relative to the working
+// directory.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (osFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+func (osFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// fsError turns an error from the filesystem into a native function error.
+func fsError(action, path string, err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	return builtinErrMsg(fmt.Sprintf("Can't %s '%s': %v.", action, path, err))
+}
+
+// fileHandle is returned by fs.open to read a file line by line, or to write to
+// it in several steps.
+type fileHandle struct {
+	mu   sync.Mutex
+	path string
+	file fs.File
+	// reader is nil if the file was opened for writing.
+	reader *bufio.Reader
+	closed bool
+}
+
+func newReadHandle(path string, file fs.File) *fileHandle {
+	return &fileHandle{path: path, file: file, reader: bufio.NewReader(file)}
+}
+
+func newWriteHandle(path string, file File) *fileHandle {
+	return &fileHandle{path: path, file: file}
+}
+
+// readLine returns the next line without its line ending, and false at the end
+// of the file.
+func (h *fileHandle) readLine() (string, bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return "", false, builtinErrMsg(fmt.Sprintf("File '%s' is closed.", h.path))
+	}
+	if h.reader == nil {
+		return "", false, builtinErrMsg(fmt.Sprintf("File '%s' is not open for reading.", h.path))
+	}
+	line, err := h.reader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", false, fsError("read file", h.path, err)
+	}
+	if line == "" && err != nil {
+		return "", false, nil
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), true, nil
+}
+
+func (h *fileHandle) write(s string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return builtinErrMsg(fmt.Sprintf("File '%s' is closed.", h.path))
+	}
+	w, ok := h.file.(io.Writer)
+	if !ok || h.reader != nil {
+		return builtinErrMsg(fmt.Sprintf("File '%s' is not open for writing.", h.path))
+	}
+	if _, err := io.WriteString(w, s); err != nil {
+		return fsError("write file", h.path, err)
+	}
+	return nil
+}
+
+func (h *fileHandle) close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return builtinErrMsg(fmt.Sprintf("File '%s' is already closed.", h.path))
+	}
+	h.closed = true
+	if err := h.file.Close(); err != nil {
+		return fsError("close file", h.path, err)
+	}
+	return nil
+}
+
+func (h *fileHandle) iterator(i *Interpreter) iterator {
+	return h
+}
+
+func (h *fileHandle) next() (any, bool, error) {
+	line, ok, err := h.readLine()
+	if !ok {
+		return nil, false, err
+	}
+	return line, true, nil
+}
+
+func (h *fileHandle) get(name token) (any, error) {
+	switch name.lexeme {
+	case "readLine":
+		return newBuiltinFn("readLine", 0, func(i *Interpreter, args []any) (any, error) {
+			line, ok, err := h.readLine()
+			if !ok {
+				return nil, err
+			}
+			return line, nil
+		}), nil
+	case "write":
+		return newBuiltinFn("write", 1, func(i *Interpreter, args []any) (any, error) {
+			s, err := stringArg("write", "Text", args, 0)
+			if err != nil {
+				return nil, err
+			}
+			return nil, h.write(s)
+		}), nil
+	case "close":
+		return newBuiltinFn("close", 0, func(i *Interpreter, args []any) (any, error) {
+			return nil, h.close()
+		}), nil
+	}
+	return nil, NewRuntimeError(name, fmt.Sprintf("Undefined properties '%s'", name.lexeme))
+}
+
+func (h *fileHandle) String() string {
+	return fmt.Sprintf("<file %s>", h.path)
+}
+
+func defineFsModule(env *environment) {
+	m := newModule("fs")
+
+	m.define("readFile", 1, func(i *Interpreter, args []any) (any, error) {
+		path, err := stringArg("fs.readFile", "Path", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		b, err := fs.ReadFile(i.fs, path)
+		if err != nil {
+			return nil, fsError("read file", path, err)
+		}
+		return string(b), nil
+	})
+
+	m.define("readLines", 1, func(i *Interpreter, args []any) (any, error) {
+		path, err := stringArg("fs.readLines", "Path", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		f, err := i.fs.Open(path)
+		if err != nil {
+			return nil, fsError("read file", path, err)
+		}
+		h := newReadHandle(path, f)
+		defer h.close()
+		out := newArray()
+		for {
+			line, ok, err := h.readLine()
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return out, nil
+			}
+			out.Append(line)
+		}
+	})
+
+	// writeTo defines a native function that writes a string to a file, opened
+	// with the given flags.
+	writeTo := func(name string, flag int) {
+		m.define(name, 2, func(i *Interpreter, args []any) (any, error) {
+			path, err := stringArg("fs."+name, "Path", args, 0)
+			if err != nil {
+				return nil, err
+			}
+			content, err := stringArg("fs."+name, "Content", args, 1)
+			if err != nil {
+				return nil, err
+			}
+			f, err := i.fs.OpenFile(path, flag, 0o644)
+			if err != nil {
+				return nil, fsError("open file", path, err)
+			}
+			if _, err := io.WriteString(f, content); err != nil {
+				f.Close()
+				return nil, fsError("write file", path, err)
+			}
+			if err := f.Close(); err != nil {
+				return nil, fsError("write file", path, err)
+			}
+			return nil, nil
+		})
+	}
+	writeTo("writeFile", os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	writeTo("appendFile", os.O_WRONLY|os.O_CREATE|os.O_APPEND)
+
+	m.define("exists", 1, func(i *Interpreter, args []any) (any, error) {
+		path, err := stringArg("fs.exists", "Path", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		_, err = i.fs.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		if err != nil {
+			return nil, fsError("stat", path, err)
+		}
+		return true, nil
+	})
+
+	m.define("listDir", 1, func(i *Interpreter, args []any) (any, error) {
+		path, err := stringArg("fs.listDir", "Path", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := i.fs.ReadDir(path)
+		if err != nil {
+			return nil, fsError("list directory", path, err)
+		}
+		out := newArray()
+		for _, entry := range entries {
+			out.Append(entry.Name())
+		}
+		return out, nil
+	})
+
+	m.define("mkdir", 1, func(i *Interpreter, args []any) (any, error) {
+		path, err := stringArg("fs.mkdir", "Path", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if err := i.fs.MkdirAll(path, 0o755); err != nil {
+			return nil, fsError("make directory", path, err)
+		}
+		return nil, nil
+	})
+
+	m.define("remove", 1, func(i *Interpreter, args []any) (any, error) {
+		path, err := stringArg("fs.remove", "Path", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if err := i.fs.Remove(path); err != nil {
+			return nil, fsError("remove", path, err)
+		}
+		return nil, nil
+	})
+
+	// open returns a file handle. The mode is "r" to read (the default), "w" to
+	// write after truncating the file, or "a" to append to it.
+	m.define("open", -1, func(i *Interpreter, args []any) (any, error) {
+		if len(args) > 2 {
+			return nil, builtinErrMsg(fmt.Sprintf("Expected at most 2 arguments but got %d.", len(args)))
+		}
+		path, err := stringArg("fs.open", "Path", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		mode := "r"
+		if len(args) == 2 {
+			mode, err = stringArg("fs.open", "Mode", args, 1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		var flag int
+		switch mode {
+		case "r":
+			f, err := i.fs.Open(path)
+			if err != nil {
+				return nil, fsError("open file", path, err)
+			}
+			return newReadHandle(path, f), nil
+		case "w":
+			flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		case "a":
+			flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		default:
+			return nil, builtinErrMsg(fmt.Sprintf("Unknown file mode '%s', expected \"r\", \"w\" or \"a\".", mode))
+		}
+		f, err := i.fs.OpenFile(path, flag, 0o644)
+		if err != nil {
+			return nil, fsError("open file", path, err)
+		}
+		return newWriteHandle(path, f), nil
+	})
+
+	env.define("fs", m)
+}
@@ -0,0 +1,180 @@
+package lox
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memFS is an in-memory FS. Reads are served by fstest.MapFS, and writes are
+// stored in the map as they happen.
+type memFS struct {
+	fstest.MapFS
+}
+
+func (m memFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	f := &memFile{fs: m, name: name}
+	if existing, ok := m.MapFS[name]; ok && flag&os.O_APPEND != 0 {
+		f.buf.Write(existing.Data)
+	}
+	m.MapFS[name] = &fstest.MapFile{Data: f.buf.Bytes(), Mode: perm}
+	return f, nil
+}
+
+func (m memFS) MkdirAll(name string, perm fs.FileMode) error {
+	m.MapFS[name] = &fstest.MapFile{Mode: fs.ModeDir | perm}
+	return nil
+}
+
+func (m memFS) Remove(name string) error {
+	if _, ok := m.MapFS[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.MapFS, name)
+	return nil
+}
+
+type memFile struct {
+	fs   memFS
+	name string
+	buf  bytes.Buffer
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	n, err := f.buf.Write(p)
+	f.fs.MapFS[f.name].Data = f.buf.Bytes()
+	return n, err
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.fs.Stat(f.name) }
+
+func (f *memFile) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrPermission}
+}
+
+func (f *memFile) Close() error { return nil }
+
+func Test_fs(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		files     map[string]string
+		code      string
+		wantEnv   map[string]any
+		wantFiles map[string]string
+		wantErr   bool
+	}{
+		{
+			desc:    "read_file",
+			files:   map[string]string{"notes.txt": "hello\nworld\n"},
+			code:    `var content = fs.readFile("notes.txt");`,
+			wantEnv: map[string]any{"content": "hello\nworld\n"},
+		},
+		{
+			desc:    "read_lines",
+			files:   map[string]string{"notes.txt": "hello\r\nworld"},
+			code:    `var lines = join(fs.readLines("notes.txt"), ",");`,
+			wantEnv: map[string]any{"lines": "hello,world"},
+		},
+		{
+			desc:    "read_missing_file",
+			code:    `fs.readFile("missing.txt");`,
+			wantErr: true,
+		},
+		{
+			desc:  "write_and_append_file",
+			files: map[string]string{"log.txt": "old"},
+			code: `fs.writeFile("log.txt", "a");
+			fs.appendFile("log.txt", "b");
+			fs.appendFile("new.txt", "c");`,
+			wantFiles: map[string]string{"log.txt": "ab", "new.txt": "c"},
+		},
+		{
+			desc:  "exists_and_remove",
+			files: map[string]string{"a.txt": ""},
+			code: `var before = fs.exists("a.txt");
+			fs.remove("a.txt");
+			var after = fs.exists("a.txt");`,
+			wantEnv: map[string]any{"before": true, "after": false},
+		},
+		{
+			desc:  "mkdir_and_list_dir",
+			files: map[string]string{"dir/b.txt": "", "dir/a.txt": ""},
+			code: `fs.mkdir("dir/sub");
+			var names = join(fs.listDir("dir"), ",");`,
+			wantEnv: map[string]any{"names": "a.txt,b.txt,sub"},
+		},
+		{
+			desc:  "handle_read_lines",
+			files: map[string]string{"data.txt": "1\n2\n3\n"},
+			code: `var f = fs.open("data.txt");
+			var first = f.readLine();
+			var rest = "";
+			for line in f {
+				rest = rest + line;
+			}
+			var eof = f.readLine();
+			f.close();`,
+			wantEnv: map[string]any{"first": "1", "rest": "23", "eof": nil},
+		},
+		{
+			desc: "handle_write",
+			code: `var f = fs.open("out.txt", "w");
+			f.write("a");
+			f.write("b");
+			f.close();`,
+			wantFiles: map[string]string{"out.txt": "ab"},
+		},
+		{
+			desc: "handle_write_after_close",
+			code: `var f = fs.open("out.txt", "w");
+			f.close();
+			f.write("a");`,
+			wantErr: true,
+		},
+		{
+			desc:    "handle_write_read_only",
+			files:   map[string]string{"data.txt": ""},
+			code:    `fs.open("data.txt").write("a");`,
+			wantErr: true,
+		},
+		{
+			desc:    "unknown_mode",
+			code:    `fs.open("data.txt", "x");`,
+			wantErr: true,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+			fsys := memFS{fstest.MapFS{}}
+			for name, content := range tC.files {
+				fsys.MapFS[name] = &fstest.MapFile{Data: []byte(content)}
+			}
+
+			er := NewLoxErrorReporter()
+			interpreter := NewInterpreter(er)
+			resolver := NewResolver(er, interpreter)
+			rt := NewRuntime(er, interpreter, resolver, WithFS(fsys))
+
+			rt.run([]byte(tC.code))
+			assert.False(t, er.HadError())
+			assert.Equal(t, tC.wantErr, er.HadRuntimeError())
+
+			for k, v := range tC.wantEnv {
+				val, exists := interpreter.globals.values[k]
+				assert.True(t, exists)
+				assert.Equal(t, v, val)
+			}
+			for name, content := range tC.wantFiles {
+				got, err := fs.ReadFile(fsys, name)
+				assert.NoError(t, err)
+				assert.Equal(t, content, string(got))
+			}
+		})
+	}
+}
@@ -13,6 +13,8 @@ const (
 	fnTypeMETHOD      fnType = "method"
 	fnTypeINITIALIZER fnType = "initializer"
 	fnTypeANONYMOUS   fnType = "anonymous"
+	fnTypeGENERATOR   fnType = "generator"
+	fnTypeASYNC       fnType = "async function"
 )
 
 type function struct {
@@ -20,6 +22,10 @@ type function struct {
 	literal       functionExpr
 	closure       *environment
 	isInitializer bool
+	// className is the class of a method, and file the script that defines
+	// the function, which are shown in tracebacks.
+	className string
+	file      string
 }
 
 func newFunction(name token, literal functionExpr, closure *environment, isInitializer bool) *function {
@@ -43,6 +49,14 @@ func (f *function) call(i *Interpreter, args []any) (any, error) {
 	for idx, param := range f.literal.params {
 		env.define(param.lexeme, args[idx])
 	}
+	if f.literal.isAsync {
+		return newAsyncCall(i, f, env), nil
+	}
+	if f.literal.isGenerator {
+		return newGenerator(i, f, env), nil
+	}
+	i.pushFrame(f)
+	defer i.popFrame()
 	err := i.executeBlock(blockStmt{f.literal.body}, env)
 	if err != nil {
 		var fnRet *functionReturn
@@ -52,7 +66,7 @@ func (f *function) call(i *Interpreter, args []any) (any, error) {
 			}
 			return fnRet.value, nil
 		}
-		return nil, err
+		return nil, i.traced(err)
 	}
 	if f.isInitializer { // Early return in initializer should return 'this'
 		return f.closure.getAt(0, "this")
@@ -67,7 +81,22 @@ func (f *function) arity() int {
 func (f *function) bind(i *instance) *function {
 	env := newEnvironment(f.closure)
 	env.define("this", i)
-	return newFunction(f.name, f.literal, env, f.isInitializer)
+	bound := newFunction(f.name, f.literal, env, f.isInitializer)
+	bound.className = f.className
+	bound.file = f.file
+	return bound
+}
+
+// qualifiedName returns the name of the function in tracebacks.
+func (f *function) qualifiedName() string {
+	switch {
+	case f.name.lexeme == "":
+		return "<anonymous fn>"
+	case f.className != "":
+		return f.className + "." + f.name.lexeme
+	default:
+		return f.name.lexeme
+	}
 }
 
 func (f *function) String() string {
@@ -0,0 +1,153 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// future holds the eventual result of an asynchronous operation, such as a
+// call to an async function. Callbacks waiting for it are run by the event
+// loop once it settles.
+type future struct {
+	loop      *eventLoop
+	mu        sync.Mutex
+	settled   bool
+	value     any
+	err       error
+	callbacks []func(val any, err error) error
+	observed  bool
+}
+
+// futureResult is what an await expression is resumed with.
+type futureResult struct {
+	value any
+	err   error
+}
+
+func newFuture(loop *eventLoop) *future {
+	return &future{loop: loop}
+}
+
+// settle resolves the future with a value, or rejects it with err. Futures can
+// only settle once, later calls are ignored.
+func (f *future) settle(val any, err error) {
+	f.mu.Lock()
+	if f.settled {
+		f.mu.Unlock()
+		return
+	}
+	f.settled, f.value, f.err = true, val, err
+	callbacks := f.callbacks
+	f.callbacks = nil
+	f.mu.Unlock()
+
+	for _, cb := range callbacks {
+		f.loop.enqueue(func() error { return cb(val, err) })
+	}
+	if err != nil {
+		// Report the error if nothing is waiting for it by the time the loop gets
+		// to it, so that failures in async functions are not silently dropped.
+		f.loop.enqueue(func() error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if !f.observed {
+				return err
+			}
+			return nil
+		})
+	}
+}
+
+// onSettle registers cb to be run by the event loop once the future settles.
+func (f *future) onSettle(cb func(val any, err error) error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.observed = true
+	if f.settled {
+		val, err := f.value, f.err
+		f.loop.enqueue(func() error { return cb(val, err) })
+		return
+	}
+	f.callbacks = append(f.callbacks, cb)
+}
+
+func (f *future) isDone() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.settled
+}
+
+func (f *future) get(name token) (any, error) {
+	switch name.lexeme {
+	case "done":
+		return newBuiltinFn("done", 0, func(i *Interpreter, args []any) (any, error) {
+			return f.isDone(), nil
+		}), nil
+	case "then":
+		return newBuiltinFn("then", 1, func(i *Interpreter, args []any) (any, error) {
+			fn, ok := args[0].(callable)
+			if !ok || fn.arity() != 1 {
+				return nil, builtinErrMsg("Can only call 'then' with a function that takes one argument.")
+			}
+			out := newFuture(f.loop)
+			site := i.site
+			f.onSettle(func(val any, err error) error {
+				if err != nil {
+					out.settle(nil, err)
+					return nil
+				}
+				out.settle(i.fork().call(site, fn, []any{val}))
+				return nil
+			})
+			return out, nil
+		}), nil
+	}
+	return nil, NewRuntimeError(name, fmt.Sprintf("Undefined properties '%s'", name.lexeme))
+}
+
+func (f *future) String() string {
+	return "<future>"
+}
+
+// newAsyncCall starts running the body of an async function and returns a
+// future for its result. The body runs until its first await, after which it
+// is resumed by the event loop every time the awaited future settles.
+func newAsyncCall(i *Interpreter, f *function, env *environment) *future {
+	fut := newFuture(i.loop)
+	depth, site := i.depth, i.site
+	co := newCoroutine(func(co *coroutine) (any, error) {
+		ai := i.fork()
+		ai.co = co
+		ai.depth = depth
+		ai.site = site
+		ai.pushFrame(f)
+		err := ai.executeBlock(blockStmt{f.literal.body}, env)
+		if err != nil {
+			var fnRet *functionReturn
+			if errors.As(err, &fnRet) {
+				return fnRet.value, nil
+			}
+			return nil, ai.traced(err)
+		}
+		return nil, nil
+	})
+	var step func(in futureResult) error
+	step = func(in futureResult) error {
+		out, done, err := co.resume(in)
+		switch {
+		case err != nil:
+			fut.settle(nil, err)
+		case done:
+			fut.settle(out, nil)
+		default:
+			awaited := out.(*future)
+			awaited.onSettle(func(val any, err error) error {
+				return step(futureResult{value: val, err: err})
+			})
+		}
+		return nil
+	}
+	step(futureResult{})
+	return fut
+}
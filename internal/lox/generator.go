@@ -0,0 +1,154 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// generator is returned when calling a function that contains a yield
+// statement. The function body runs lazily, one yield at a time, as values are
+// requested from the generator.
+//
+// A generator that is suspended at a yield keeps a goroutine. It is closed
+// when a for-in loop over it stops early, and otherwise once it is garbage
+// collected, but scripts can call close() to release it sooner.
+type generator struct {
+	fn *function
+	// gi is the interpreter the body runs on.
+	gi *Interpreter
+	co *coroutine
+	// mu is held while the generator body is running.
+	mu      sync.Mutex
+	peeked  bool
+	peekVal any
+	peekOk  bool
+}
+
+func newGenerator(i *Interpreter, f *function, env *environment) *generator {
+	g := &generator{fn: f, gi: i.fork()}
+	gi := g.gi
+	g.co = newCoroutine(func(co *coroutine) (any, error) {
+		gi.co = co
+		err := gi.executeBlock(blockStmt{f.literal.body}, env)
+		if err != nil {
+			var fnRet *functionReturn
+			if errors.As(err, &fnRet) {
+				return nil, nil
+			}
+			return nil, gi.traced(err)
+		}
+		return nil, nil
+	})
+	// The goroutine of the body doesn't refer to the generator, so it can be
+	// collected while the body is suspended, which then unwinds it.
+	runtime.SetFinalizer(g, func(g *generator) {
+		go g.co.close()
+	})
+	return g
+}
+
+// next returns the next yielded value, and false once the generator is done.
+// The body is resumed as if it was called by i at site.
+func (g *generator) next(i *Interpreter, site token) (any, bool, error) {
+	if !g.mu.TryLock() {
+		return nil, false, builtinErrMsg("Generator is already running.")
+	}
+	defer g.mu.Unlock()
+	return g.resume(i, site)
+}
+
+func (g *generator) resume(i *Interpreter, site token) (any, bool, error) {
+	if g.peeked {
+		g.peeked = false
+		return g.peekVal, g.peekOk, nil
+	}
+	// Resuming the body is a call from site, so its depth counts towards the
+	// limit and its tracebacks lead to site, even though it runs on another
+	// goroutine. It is suspended at a yield in its own frame.
+	if i.depth >= i.maxDepth {
+		return nil, false, NewRuntimeError(site, "Stack overflow.")
+	}
+	g.gi.depth = i.depth + 1
+	g.gi.site = site
+	g.gi.frames = append(slices.Clip(i.frames), callFrame{fn: g.fn, site: site})
+	val, done, err := g.co.resume(nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if done {
+		return nil, false, nil
+	}
+	return val, true, nil
+}
+
+// peek returns the next yielded value without consuming it.
+func (g *generator) peek(i *Interpreter, site token) (any, bool, error) {
+	if !g.mu.TryLock() {
+		return nil, false, builtinErrMsg("Generator is already running.")
+	}
+	defer g.mu.Unlock()
+	if g.peeked {
+		return g.peekVal, g.peekOk, nil
+	}
+	val, ok, err := g.resume(i, site)
+	if err != nil {
+		return nil, false, err
+	}
+	g.peeked, g.peekVal, g.peekOk = true, val, ok
+	return val, ok, nil
+}
+
+func (g *generator) close() error {
+	if !g.mu.TryLock() {
+		return builtinErrMsg("Generator is already running.")
+	}
+	defer g.mu.Unlock()
+	g.peeked = false
+	g.co.close()
+	return nil
+}
+
+func (g *generator) iterator(i *Interpreter) iterator {
+	return &generatorIterator{g: g, i: i}
+}
+
+// generatorIterator resumes a generator from a for-in loop run by i, at the
+// site of the loop.
+type generatorIterator struct {
+	g *generator
+	i *Interpreter
+}
+
+func (it *generatorIterator) next() (any, bool, error) {
+	return it.g.next(it.i, it.i.site)
+}
+
+func (g *generator) get(name token) (any, error) {
+	switch name.lexeme {
+	case "next":
+		return newBuiltinFn("next", 0, func(i *Interpreter, args []any) (any, error) {
+			val, _, err := g.next(i, i.site)
+			return val, err
+		}), nil
+	case "done":
+		return newBuiltinFn("done", 0, func(i *Interpreter, args []any) (any, error) {
+			_, ok, err := g.peek(i, i.site)
+			return !ok, err
+		}), nil
+	case "close":
+		return newBuiltinFn("close", 0, func(i *Interpreter, args []any) (any, error) {
+			return nil, g.close()
+		}), nil
+	}
+	return nil, NewRuntimeError(name, fmt.Sprintf("Undefined properties '%s'", name.lexeme))
+}
+
+func (g *generator) String() string {
+	if g.fn.name.lexeme == "" {
+		return "<generator>"
+	}
+	return fmt.Sprintf("<generator %s>", g.fn.name.lexeme)
+}
@@ -0,0 +1,211 @@
+package lox
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_generator(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		code    string
+		input   string
+		want    any
+		wantErr bool
+	}{
+		{
+			desc: "first_value",
+			code: `fn gen() { yield 1; yield 2; }
+			var g = gen();`,
+			input: "g.next()",
+			want:  1,
+		},
+		{
+			desc: "exhausted",
+			code: `fn gen() { yield 1; }
+			var g = gen();
+			g.next();`,
+			input: "g.next()",
+			want:  nil,
+		},
+		{
+			desc: "done_does_not_consume",
+			code: `fn gen() { yield 1; }
+			var g = gen();
+			g.done();`,
+			input: "g.next()",
+			want:  1,
+		},
+		{
+			desc: "done_after_last_value",
+			code: `fn gen() { yield 1; }
+			var g = gen();
+			g.next();`,
+			input: "g.done()",
+			want:  true,
+		},
+		{
+			desc: "closed",
+			code: `fn gen() { while true { yield 1; } }
+			var g = gen();
+			g.next();
+			g.close();`,
+			input: "g.done()",
+			want:  true,
+		},
+		{
+			desc: "captures_closure",
+			code: `fn counter(step) {
+				var total = 0;
+				return fn() {
+					while true {
+						total = total + step;
+						yield total;
+					}
+				};
+			}
+			var g = counter(5)();
+			g.next();`,
+			input: "g.next()",
+			want:  10,
+		},
+		{
+			desc: "for_in_generator",
+			code: `fn upTo(n) {
+				var i = 0;
+				while i < n {
+					yield i;
+					i = i + 1;
+				}
+			}
+			var sum = 0;
+			for x in upTo(4) {
+				sum = sum + x;
+			}`,
+			input: "sum",
+			want:  6,
+		},
+		{
+			desc: "for_in_infinite_generator_break",
+			code: `fn naturals() {
+				var n = 0;
+				while true {
+					n = n + 1;
+					yield n;
+				}
+			}
+			var last = 0;
+			for n in naturals() {
+				if n > 3 {
+					break;
+				}
+				last = n;
+			}`,
+			input: "last",
+			want:  3,
+		},
+		{
+			desc: "runtime_error_in_body",
+			code: `fn gen() { yield 1 - "one"; }
+			var g = gen();`,
+			input:   "g.next()",
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := runScript(t, tC.code, tC.input)
+			assert.Equal(t, tC.want, got)
+			if tC.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_generator_released(t *testing.T) {
+	testCases := []struct {
+		desc string
+		code string
+	}{
+		{
+			desc: "break",
+			code: `for n in naturals() { if n > 3 { break; } }`,
+		},
+		{
+			desc: "labeled_break",
+			code: `outer: for x in [1, 2] {
+				for n in naturals() { break outer; }
+			}`,
+		},
+		{
+			desc: "return",
+			code: `fn first() { for n in naturals() { return n; } }
+			first();`,
+		},
+		{
+			desc: "runtime_error",
+			code: `for n in naturals() { n - "one"; }`,
+		},
+		{
+			desc: "closed",
+			code: `var g = naturals(); g.next(); g.close();`,
+		},
+		{
+			desc: "never_resumed",
+			code: `var g = naturals();`,
+		},
+		{
+			desc: "abandoned",
+			code: `fn second() { var g = naturals(); g.next(); return g.next(); }
+			second();`,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			code := `fn naturals() {
+				var n = 0;
+				while true {
+					n = n + 1;
+					yield n;
+				}
+			}
+			` + tC.code
+			scanner := NewScanner(nil, []byte(code))
+			tokens, err := scanner.ScanTokens()
+			require.NoError(t, err)
+			stmts, err := NewParser(nil, tokens).Parse()
+			require.NoError(t, err)
+
+			before := runtime.NumGoroutine()
+			for range 20 {
+				interpreter := NewInterpreter(nil)
+				require.NoError(t, NewResolver(nil, interpreter).Resolve(stmts))
+				for _, stmt := range stmts {
+					if interpreter.execute(stmt) != nil {
+						break
+					}
+				}
+			}
+			// Closed generators finish their goroutines shortly after the loop,
+			// and abandoned ones once they are collected.
+			deadline := time.Now().Add(time.Second)
+			for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+				runtime.GC()
+				time.Sleep(10 * time.Millisecond)
+			}
+			assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+		})
+	}
+}
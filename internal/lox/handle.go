@@ -0,0 +1,169 @@
+package lox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Func is a handle to a Lox function, class or bound method, which Go code
+// can keep and call later. It may be called from any goroutine: calls made
+// while a script is running on the VM wait for it to finish, except those
+// made by Go functions called by the script on the goroutine they were called
+// on, which run within it.
+type Func struct {
+	vm   *VM
+	name token
+	fn   callable
+}
+
+// Func returns a handle to the global function or class called name.
+func (vm *VM) Func(name string) (*Func, error) {
+	val, ok := vm.GetGlobal(name)
+	if !ok {
+		return nil, fmt.Errorf("undefined variable '%s'", name)
+	}
+	return vm.FuncOf(val)
+}
+
+// FuncOf returns a handle to a function or class value, such as a callback
+// passed to a Go function.
+func (vm *VM) FuncOf(v Value) (*Func, error) {
+	fn, ok := v.v.(callable)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a function", Value{v.v})
+	}
+	name := newToken(IDENTIFIER, fmt.Sprint(v.v), nil, 0, 0)
+	switch f := fn.(type) {
+	case *function:
+		if f.name.lexeme != "" {
+			name = f.name
+		}
+	case *class:
+		name.lexeme = f.name
+	}
+	return &Func{vm: vm, name: name, fn: fn}, nil
+}
+
+// Method returns a handle to the method called name of an instance or Go
+// object, bound to it.
+func (vm *VM) Method(obj Value, name string) (*Func, error) {
+	o, ok := obj.v.(object)
+	if !ok {
+		return nil, fmt.Errorf("%s has no methods", obj)
+	}
+	tok := newToken(IDENTIFIER, name, nil, 0, 0)
+	method, err := o.get(tok)
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := method.(callable)
+	if !ok {
+		return nil, fmt.Errorf("'%s' of %s is not a method", name, obj)
+	}
+	if f, ok := fn.(*function); ok {
+		tok = f.name
+	}
+	return &Func{vm: vm, name: tok, fn: fn}, nil
+}
+
+// Arity returns the number of arguments the function takes, or a negative
+// number if it takes a variable number of arguments.
+func (f *Func) Arity() int {
+	return f.fn.arity()
+}
+
+// Call calls the function with args, which are converted as by ValueOf, and
+// returns its result. Errors raised by the function are returned as
+// RuntimeErrors, and as ExitError if it calls exit.
+//
+// Unless Call is called from a Go function called by a script, timers and
+// async functions started by the call are run to completion before it
+// returns.
+func (f *Func) Call(args ...any) (Value, error) {
+	return f.CallContext(context.Background(), args...)
+}
+
+// CallContext is like Call, but stops the function with an InterruptError once
+// ctx is done, including while it waits for a script to finish. When called
+// from a Go function called by a script, the function is stopped with the
+// script instead.
+func (f *Func) CallContext(ctx context.Context, args ...any) (Value, error) {
+	in := make([]any, len(args))
+	for idx, arg := range args {
+		val, err := ValueOf(arg)
+		if err != nil {
+			return Value{}, fmt.Errorf("argument %d: %w", idx+1, err)
+		}
+		in[idx] = val.v
+	}
+	if err := checkArity(f.name, f.fn, len(in)); err != nil {
+		return Value{}, err
+	}
+
+	vm := f.vm
+	if caller := vm.i.goCalls.caller(goroutineID()); caller != nil {
+		// The VM is running a script that called this function through Go on
+		// this goroutine, and holds the VM and runs the event loop itself.
+		return f.call(caller, in)
+	}
+	if err := vm.lock(ctx); err != nil {
+		return Value{}, err
+	}
+	defer vm.unlock()
+	res, err := f.run(ctx, in)
+	if err != nil {
+		// The timers left by a failed call are dropped, so that they don't
+		// run with the next one.
+		vm.rt.loop.clear()
+	}
+	return res, err
+}
+
+// run calls the function on the interpreter of the VM, and runs the event loop
+// once it returns.
+func (f *Func) run(ctx context.Context, args []any) (Value, error) {
+	vm := f.vm
+	vm.er.reset()
+	vm.i.start(ctx)
+	res, err := f.call(vm.i, args)
+	if err != nil {
+		return Value{}, err
+	}
+	if err := vm.rt.runEventLoop(); err != nil {
+		return Value{}, vm.runError(err)
+	}
+	if err := vm.er.err(); err != nil {
+		return Value{}, err
+	}
+	return res, nil
+}
+
+// call calls the function on behalf of caller, which is the interpreter of the
+// VM or the one that called the Go function calling it.
+func (f *Func) call(caller *Interpreter, args []any) (Value, error) {
+	// The function runs on its own interpreter, so that it doesn't disturb the
+	// environment of a script that is running. It is nested in the calls of
+	// caller, so that recursion through Go is bounded by the maximum depth.
+	i := caller.fork()
+	i.depth = caller.depth
+	// Calling counts as a step, so that a call made once ctx is done doesn't
+	// run at all.
+	res, err := i.call(f.name, f.fn, args)
+	if err != nil {
+		var rtErr RuntimeError
+		switch {
+		case unwinds(err):
+			return Value{}, f.vm.runError(err)
+		case errors.As(err, &rtErr):
+			return Value{}, rtErr
+		default:
+			return Value{}, NewRuntimeError(f.name, err.Error())
+		}
+	}
+	return Value{res}, nil
+}
+
+func (f *Func) String() string {
+	return fmt.Sprint(f.fn)
+}
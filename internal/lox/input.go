@@ -0,0 +1,132 @@
+package lox
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// inputReader reads the standard input of scripts. It is shared by forked
+// interpreters, so reads are serialized.
+type inputReader struct {
+	mu sync.Mutex
+	r  *bufio.Reader
+}
+
+func newInputReader(r io.Reader) *inputReader {
+	return &inputReader{r: bufio.NewReader(r)}
+}
+
+// readLine returns the next line without its line ending, and false at the end
+// of the input.
+func (in *inputReader) readLine() (string, bool, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	line, err := in.r.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", false, builtinErrMsg(fmt.Sprintf("Can't read input: %v.", err))
+	}
+	if line == "" && err != nil {
+		return "", false, nil
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), true, nil
+}
+
+// readAll returns the rest of the input.
+func (in *inputReader) readAll() (string, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	b, err := io.ReadAll(in.r)
+	if err != nil {
+		return "", builtinErrMsg(fmt.Sprintf("Can't read input: %v.", err))
+	}
+	return string(b), nil
+}
+
+// buffer reads the rest of the input into memory, so that the underlying
+// reader can be closed without losing what was not read yet.
+func (in *inputReader) buffer() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	b, _ := io.ReadAll(in.r)
+	in.r = bufio.NewReader(bytes.NewReader(b))
+}
+
+// readToken returns the next run of non-space characters, and false once only
+// spaces are left.
+func (in *inputReader) readToken() (string, bool, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	var sb strings.Builder
+	for {
+		r, _, err := in.r.ReadRune()
+		if errors.Is(err, io.EOF) {
+			return sb.String(), sb.Len() > 0, nil
+		}
+		if err != nil {
+			return "", false, builtinErrMsg(fmt.Sprintf("Can't read input: %v.", err))
+		}
+		if unicode.IsSpace(r) {
+			if sb.Len() > 0 {
+				return sb.String(), true, nil
+			}
+			continue
+		}
+		sb.WriteRune(r)
+	}
+}
+
+func defineInputFns(env *environment) {
+	// readLine and readToken return nil at the end of the input.
+	env.define("readLine", newBuiltinFn("readLine", 0, func(i *Interpreter, args []any) (any, error) {
+		line, ok, err := i.stdin.readLine()
+		if !ok {
+			return nil, err
+		}
+		return line, nil
+	}))
+
+	env.define("readToken", newBuiltinFn("readToken", 0, func(i *Interpreter, args []any) (any, error) {
+		tok, ok, err := i.stdin.readToken()
+		if !ok {
+			return nil, err
+		}
+		return tok, nil
+	}))
+
+	env.define("readAll", newBuiltinFn("readAll", 0, func(i *Interpreter, args []any) (any, error) {
+		return i.stdin.readAll()
+	}))
+
+	// parseInt and parseFloat return nil if the string is not a number, so that a
+	// default can be given with '??'. Surrounding spaces are ignored.
+	env.define("parseInt", newBuiltinFn("parseInt", 1, func(i *Interpreter, args []any) (any, error) {
+		s, err := stringArg("parseInt", "Value", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return nil, nil
+		}
+		return n, nil
+	}))
+
+	env.define("parseFloat", newBuiltinFn("parseFloat", 1, func(i *Interpreter, args []any) (any, error) {
+		s, err := stringArg("parseFloat", "Value", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, nil
+		}
+		return f, nil
+	}))
+}
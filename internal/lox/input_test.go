@@ -0,0 +1,84 @@
+package lox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_input(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		stdin   string
+		code    string
+		wantEnv map[string]any
+		wantErr bool
+	}{
+		{
+			desc:  "read_lines_until_eof",
+			stdin: "first\r\nsecond",
+			code: `var a = readLine();
+			var b = readLine();
+			var c = readLine();`,
+			wantEnv: map[string]any{"a": "first", "b": "second", "c": nil},
+		},
+		{
+			desc:  "read_all",
+			stdin: "line\nrest\n",
+			code: `var first = readLine();
+			var rest = readAll();
+			var empty = readAll();`,
+			wantEnv: map[string]any{"first": "line", "rest": "rest\n", "empty": ""},
+		},
+		{
+			desc:  "read_tokens",
+			stdin: "  3 4\n\t5  \n",
+			code: `var sum = 0;
+			var tok = readToken();
+			while tok != nil {
+				sum = sum + parseInt(tok);
+				tok = readToken();
+			}`,
+			wantEnv: map[string]any{"sum": 12},
+		},
+		{
+			desc: "parse_int",
+			code: `var ok = parseInt(" 42 ");
+			var bad = parseInt("4.2");`,
+			wantEnv: map[string]any{"ok": 42, "bad": nil},
+		},
+		{
+			desc: "parse_float",
+			code: `var ok = parseFloat("4.5");
+			var whole = parseFloat("4");
+			var bad = parseFloat("four") ?? 0;`,
+			wantEnv: map[string]any{"ok": 4.5, "whole": 4.0, "bad": 0},
+		},
+		{
+			desc:    "parse_non_string",
+			code:    `parseInt(42);`,
+			wantErr: true,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+			er := NewLoxErrorReporter()
+			interpreter := NewInterpreter(er)
+			resolver := NewResolver(er, interpreter)
+			rt := NewRuntime(er, interpreter, resolver, WithStdin(strings.NewReader(tC.stdin)))
+
+			rt.run([]byte(tC.code))
+			assert.False(t, er.HadError())
+			assert.Equal(t, tC.wantErr, er.HadRuntimeError())
+
+			for k, v := range tC.wantEnv {
+				val, exists := interpreter.globals.values[k]
+				assert.True(t, exists)
+				assert.Equal(t, v, val)
+			}
+		})
+	}
+}
@@ -1,9 +1,13 @@
 package lox
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+)
 
 type instance struct {
 	class  *class
+	mu     sync.RWMutex
 	fields map[string]any
 }
 
@@ -17,3 +21,31 @@ func newInstance(c *class) *instance {
 func (i *instance) String() string {
 	return fmt.Sprintf("%s instance", i.class.name)
 }
+
+func (i *instance) get(name token) (any, error) {
+	i.mu.RLock()
+	val, ok := i.fields[name.lexeme]
+	i.mu.RUnlock()
+	if ok {
+		return val, nil
+	}
+	method, ok := i.class.findMethod(name.lexeme)
+	if ok {
+		return method.bind(i), nil
+	}
+	return nil, NewRuntimeError(name, fmt.Sprintf("Undefined properties '%s'", name.lexeme))
+}
+
+// has reports whether the instance has a field called name.
+func (i *instance) has(name string) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	_, ok := i.fields[name]
+	return ok
+}
+
+func (i *instance) set(name token, val any) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.fields[name.lexeme] = val
+}
@@ -1,30 +1,118 @@
 package lox
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
 	"strconv"
+	"sync"
 )
 
 type Interpreter struct {
 	er      ErrorReporter
 	globals *environment
 	locals  map[expr]int
-	env     *environment
-}
+	// localsMu guards locals, which is shared with forked interpreters.
+	localsMu *sync.RWMutex
+	env      *environment
+	// co is the coroutine of the generator or async function whose body this
+	// interpreter is executing, if any.
+	co *coroutine
+	// loop runs timers and async callbacks. It is replaced by the one owned by
+	// the Runtime when the interpreter is run through it.
+	loop *eventLoop
+	// fs is the filesystem used by the fs module.
+	fs FS
+	// stdin is read by the input native functions.
+	stdin *inputReader
+	// stdout is written to by print and the printing native functions.
+	stdout *syncWriter
+	// random generates the numbers of the random module.
+	random *randomSource
+	// caps are the capabilities of scripts, which have all of them if it is
+	// nil.
+	caps map[Capability]bool
+	// file is the script being run, if it was read from a file. frames are the
+	// calls of Lox functions in progress, and site is the token of the call
+	// being made, which become the traceback of runtime errors.
+	file   string
+	frames []callFrame
+	site   token
+	// ctx stops the current run once it is done, and steps limits how long it
+	// may run, if it is not nil. Both are checked by loops and calls.
+	ctx   context.Context
+	steps *budget
+	// mem limits the approximate number of bytes allocated during the run, if
+	// it is not nil.
+	mem *budget
+	// depth is the number of calls in progress on this interpreter, which may
+	// not exceed maxDepth. Forks run on their own goroutine, so they start
+	// from zero.
+	depth    int
+	maxDepth int
+	// goCalls are the goroutines running Go functions bound by a VM, which
+	// may call back into the script through Func handles.
+	goCalls *goCallers
+}
+
+// defaultMaxDepth is the default maximum call depth, which is well below the
+// depth at which Go itself runs out of stack.
+const defaultMaxDepth = 10000
 
 func NewInterpreter(er ErrorReporter) *Interpreter {
 	globals := newGlobalEnvironment()
 	defineNativeFns(globals)
 	return &Interpreter{
-		er:      er,
-		globals: globals,
-		locals:  make(map[expr]int, 0),
-		env:     globals,
+		er:       er,
+		globals:  globals,
+		locals:   make(map[expr]int, 0),
+		localsMu: &sync.RWMutex{},
+		env:      globals,
+		loop:     newEventLoop(systemClock{}),
+		fs:       osFS{},
+		stdin:    newInputReader(os.Stdin),
+		stdout:   newSyncWriter(os.Stdout),
+		random:   newRandomSource(),
+		ctx:      context.Background(),
+		maxDepth: defaultMaxDepth,
+		goCalls:  newGoCallers(),
+	}
+}
+
+// fork returns an interpreter that shares the globals and resolved locals of i
+// but keeps track of its own current environment, so that it can execute on
+// another goroutine.
+func (i *Interpreter) fork() *Interpreter {
+	return &Interpreter{
+		er:       i.er,
+		globals:  i.globals,
+		locals:   i.locals,
+		localsMu: i.localsMu,
+		env:      i.globals,
+		loop:     i.loop,
+		fs:       i.fs,
+		stdin:    i.stdin,
+		stdout:   i.stdout,
+		random:   i.random,
+		caps:     i.caps,
+		file:     i.file,
+		ctx:      i.ctx,
+		steps:    i.steps,
+		mem:      i.mem,
+		maxDepth: i.maxDepth,
+		goCalls:  i.goCalls,
 	}
 }
 
 func (i *Interpreter) Interpret(stmts []stmt) error {
+	return i.InterpretContext(context.Background(), stmts)
+}
+
+// InterpretContext is like Interpret, but stops with an InterruptError once
+// ctx is done.
+func (i *Interpreter) InterpretContext(ctx context.Context, stmts []stmt) error {
+	i.start(ctx)
 	for _, stmt := range stmts {
 		err := i.execute(stmt)
 		if err != nil {
@@ -40,11 +128,29 @@ func (i *Interpreter) Interpret(stmts []stmt) error {
 }
 
 func (i *Interpreter) resolve(e expr, depth int) {
-	i.locals[e] = depth
+	i.localsMu.Lock()
+	defer i.localsMu.Unlock()
+	i.locals[localKey(e)] = depth
+}
+
+func (i *Interpreter) localDistance(e expr) (int, bool) {
+	i.localsMu.RLock()
+	defer i.localsMu.RUnlock()
+	distance, ok := i.locals[localKey(e)]
+	return distance, ok
+}
+
+// localKey returns the key of a resolved expression in locals. Assignments are
+// keyed by their target only, as their value may not be hashable.
+func localKey(e expr) expr {
+	if assign, ok := e.(assignExpr); ok {
+		return assignExpr{name: assign.name}
+	}
+	return e
 }
 
 func (i *Interpreter) lookUpVariable(name token, e expr) (any, error) {
-	distance, ok := i.locals[e]
+	distance, ok := i.localDistance(e)
 	if !ok {
 		return i.globals.get(name)
 	}
@@ -92,6 +198,26 @@ func (i *Interpreter) isTruthy(val any) bool {
 	return true
 }
 
+func (i *Interpreter) visitAwaitExpr(e awaitExpr) (any, error) {
+	val, err := i.evaluate(e.value)
+	if err != nil {
+		return nil, err
+	}
+	fut, ok := val.(*future)
+	if !ok {
+		return val, nil
+	}
+	if i.co == nil {
+		return nil, NewRuntimeError(e.keyword, "Can only await inside an async function.")
+	}
+	in, err := i.co.yield(fut)
+	if err != nil {
+		return nil, err
+	}
+	res := in.(futureResult)
+	return res.value, res.err
+}
+
 func (i *Interpreter) visitBinaryExpr(e binaryExpr) (any, error) {
 	left, err := i.evaluate(e.left)
 	if err != nil {
@@ -150,6 +276,9 @@ func (i *Interpreter) visitBinaryExpr(e binaryExpr) (any, error) {
 		}
 		leftStr, rightStr, err := i.assertStringOperands(left, right)
 		if err == nil {
+			if err := i.alloc(stringSize + int64(len(leftStr)+len(rightStr))); err != nil {
+				return nil, NewRuntimeError(e.operator, err.Error())
+			}
 			return leftStr + rightStr, nil
 		}
 		return nil, NewRuntimeError(e.operator, "Operands must be either numbers or strings.")
@@ -178,22 +307,22 @@ func (i *Interpreter) visitBinaryExpr(e binaryExpr) (any, error) {
 		}
 		return leftNum <= rightNum, nil
 	case BANG_EQUAL:
-		leftNum, rightNum, err := i.assertFloatOperands(left, right)
-		if err == nil {
-			return leftNum != rightNum, nil
-		}
-		return left != right, nil
+		return !i.isEqual(left, right), nil
 	case EQUAL_EQUAL:
-		leftNum, rightNum, err := i.assertFloatOperands(left, right)
-		if err == nil {
-			return leftNum == rightNum, nil
-		}
-		return left == right, nil
+		return i.isEqual(left, right), nil
 	default:
 		return nil, NewRuntimeError(e.operator, "Undefined binary operator.")
 	}
 }
 
+func (i *Interpreter) isEqual(left, right any) bool {
+	leftNum, rightNum, err := i.assertFloatOperands(left, right)
+	if err == nil {
+		return leftNum == rightNum
+	}
+	return left == right
+}
+
 func (i *Interpreter) assertFloat(val any) (float64, error) {
 	switch v := val.(type) {
 	case float64:
@@ -277,7 +406,7 @@ func (i *Interpreter) visitAssignExpr(e assignExpr) (any, error) {
 	if err != nil {
 		return nil, err
 	}
-	distance, ok := i.locals[e]
+	distance, ok := i.localDistance(e)
 	if ok {
 		err = i.env.assignAt(distance, e.name, val)
 	} else {
@@ -295,7 +424,11 @@ func (i *Interpreter) visitLogicalExpr(e logicalExpr) (any, error) {
 		return nil, err
 	}
 	// Short-circuit
-	if e.operator.tokenType == OR {
+	if e.operator.tokenType == QUESTION_QUESTION {
+		if leftVal != nil {
+			return leftVal, nil
+		}
+	} else if e.operator.tokenType == OR {
 		if i.isTruthy(leftVal) {
 			return leftVal, nil
 		}
@@ -307,39 +440,101 @@ func (i *Interpreter) visitLogicalExpr(e logicalExpr) (any, error) {
 }
 
 func (i *Interpreter) visitCallExpr(e callExpr) (any, error) {
-	callee, err := i.evaluate(e.callee)
+	function, args, err := i.evaluateCall(e)
 	if err != nil {
 		return nil, err
 	}
+	return i.call(e.paren, function, args)
+}
+
+// evaluateCall evaluates the callee and arguments of a call expression, and
+// checks that they can be called together.
+func (i *Interpreter) evaluateCall(e callExpr) (callable, []any, error) {
+	callee, err := i.evaluate(e.callee)
+	if err != nil {
+		return nil, nil, err
+	}
 	args := make([]any, len(e.arguments))
 	for idx, argExpr := range e.arguments {
 		arg, err := i.evaluate(argExpr)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		args[idx] = arg
 	}
 	function, ok := callee.(callable)
 	if !ok {
-		return nil, NewRuntimeError(e.paren, "Can only call functions and classes.")
+		return nil, nil, NewRuntimeError(e.paren, "Can only call functions and classes.")
 	}
-	if function.arity() == -1 {
-		if len(args) == 0 {
-			return nil, NewRuntimeError(e.paren, "Expected at least 1 arguments but got 0.")
-		}
-	} else if function.arity() != len(args) {
-		return nil, NewRuntimeError(
-			e.paren,
-			fmt.Sprintf("Expected %d arguments but got %d.", function.arity(), len(args)),
+	if err := checkArity(e.paren, function, len(args)); err != nil {
+		return nil, nil, err
+	}
+	return function, args, nil
+}
+
+// checkArity checks that function can be called with argCount arguments.
+func checkArity(paren token, function callable, argCount int) error {
+	if function.arity() == anyArity {
+		return nil
+	} else if function.arity() == -1 {
+		if argCount == 0 {
+			return NewRuntimeError(paren, "Expected at least 1 arguments but got 0.")
+		}
+	} else if function.arity() != argCount {
+		return NewRuntimeError(
+			paren,
+			fmt.Sprintf("Expected %d arguments but got %d.", function.arity(), argCount),
 		)
 	}
+	return nil
+}
+
+func (i *Interpreter) call(paren token, function callable, args []any) (any, error) {
+	if err := i.step(); err != nil {
+		return nil, err
+	}
+	if i.depth >= i.maxDepth {
+		return nil, NewRuntimeError(paren, "Stack overflow.")
+	}
+	i.depth++
+	site := i.site
+	i.site = paren
 	res, err := function.call(i, args)
+	i.site = site
+	i.depth--
+	if _, ok := function.(builtinFn); ok && err == nil {
+		// Lox functions account for what they allocate, but natives only
+		// return it.
+		err = i.alloc(sizeOf(res))
+	}
 	if err != nil {
-		return nil, NewRuntimeError(e.paren, err.Error())
+		// Errors raised inside the function keep their own location, so that
+		// a stack overflow points at the call that was too deep.
+		var rtErr RuntimeError
+		if errors.As(err, &rtErr) || unwinds(err) {
+			return nil, err
+		}
+		// A Go function may return the exit of a Lox function it called.
+		var exitErr *ExitError
+		if errors.As(err, &exitErr) {
+			return nil, &scriptExit{code: exitErr.Code}
+		}
+		return nil, NewRuntimeError(paren, err.Error())
 	}
 	return res, err
 }
 
+func (i *Interpreter) visitSpawnExpr(e spawnExpr) (any, error) {
+	if !i.allows(CapConcurrency) {
+		return nil, NewRuntimeError(e.keyword, permissionDenied("spawn").Error())
+	}
+	function, args, err := i.evaluateCall(e.call)
+	if err != nil {
+		return nil, err
+	}
+	return newTask(i, e.call.paren, function, args), nil
+}
+
 func (i *Interpreter) visitTernaryExpr(e ternaryExpr) (any, error) {
 	condition, err := i.evaluate(e.condition)
 	if err != nil {
@@ -353,23 +548,30 @@ func (i *Interpreter) visitTernaryExpr(e ternaryExpr) (any, error) {
 }
 
 func (i *Interpreter) visitGetExpr(e getExpr) (any, error) {
-	object, err := i.evaluate(e.object)
+	val, err := i.evaluate(e.object)
 	if err != nil {
 		return nil, err
 	}
-	instance, ok := object.(*instance)
+	if val == nil && e.optional {
+		return nil, &nilChain{}
+	}
+	obj, ok := val.(object)
 	if !ok {
 		return nil, NewRuntimeError(e.name, "Only instances have properties.")
 	}
-	val, ok := instance.fields[e.name.lexeme]
-	if ok {
-		return val, nil
-	}
-	method, ok := instance.class.findMethod(e.name.lexeme)
-	if ok {
-		return method.bind(instance), nil
+	return obj.get(e.name)
+}
+
+func (i *Interpreter) visitOptionalChainExpr(e optionalChainExpr) (any, error) {
+	val, err := i.evaluate(e.chain)
+	if err != nil {
+		var short *nilChain
+		if errors.As(err, &short) {
+			return nil, nil
+		}
+		return nil, err
 	}
-	return nil, NewRuntimeError(e.name, fmt.Sprintf("Undefined properties '%s'", e.name.lexeme))
+	return val, nil
 }
 
 func (i *Interpreter) visitSetExpr(e setExpr) (any, error) {
@@ -381,11 +583,22 @@ func (i *Interpreter) visitSetExpr(e setExpr) (any, error) {
 	if err != nil {
 		return nil, err
 	}
-	instance, ok := object.(*instance)
-	if !ok {
+	switch obj := object.(type) {
+	case *instance:
+		// Fields are stored in a map, so a new one takes a map entry.
+		if i.mem != nil && !obj.has(e.name.lexeme) {
+			if err := i.alloc(mapEntrySize); err != nil {
+				return nil, NewRuntimeError(e.name, err.Error())
+			}
+		}
+		obj.set(e.name, val)
+	case fieldSetter:
+		if err := obj.set(e.name, val); err != nil {
+			return nil, err
+		}
+	default:
 		return nil, NewRuntimeError(e.name, "Only instances have fields.")
 	}
-	instance.fields[e.name.lexeme] = val
 	return val, nil
 }
 
@@ -394,7 +607,7 @@ func (i *Interpreter) visitThisExpr(e thisExpr) (any, error) {
 }
 
 func (i *Interpreter) visitSuperExpr(e superExpr) (any, error) {
-	distance, ok := i.locals[e]
+	distance, ok := i.localDistance(e)
 	if !ok {
 		return nil, errors.New("could not find super expr in locals")
 	}
@@ -422,7 +635,9 @@ func (i *Interpreter) visitSuperExpr(e superExpr) (any, error) {
 }
 
 func (i *Interpreter) visitFunctionExpr(e functionExpr) (any, error) {
-	return newAnonymousFunction(e, i.env), nil
+	fn := newAnonymousFunction(e, i.env)
+	fn.file = i.file
+	return fn, nil
 }
 
 func (i *Interpreter) visitArrayExpr(e arrayExpr) (any, error) {
@@ -435,6 +650,9 @@ func (i *Interpreter) visitArrayExpr(e arrayExpr) (any, error) {
 		}
 		values[idx] = val
 	}
+	if err := i.alloc(arraySize + int64(len(values))*valueSize); err != nil {
+		return nil, NewRuntimeError(e.bracket, err.Error())
+	}
 	out.Append(values...)
 	return out, nil
 }
@@ -444,11 +662,89 @@ func (i *Interpreter) visitIndexExpr(e indexExpr) (any, error) {
 	if err != nil {
 		return nil, err
 	}
-	array, ok := callee.(*array)
-	if ok {
-		return i.indexArray(e.bracket, array, e.index)
+	if callee == nil && e.optional {
+		return nil, &nilChain{}
 	}
-	return nil, NewRuntimeError(e.bracket, "Can only index arrays.")
+	switch callee := callee.(type) {
+	case *array:
+		return i.indexArray(e.bracket, callee, e.index)
+	case *loxMap:
+		key, err := i.evaluate(e.index)
+		if err != nil {
+			return nil, err
+		}
+		val, _, err := callee.Get(key)
+		if err != nil {
+			return nil, NewRuntimeError(e.bracket, err.Error())
+		}
+		return val, nil
+	}
+	return nil, NewRuntimeError(e.bracket, "Can only index arrays and maps.")
+}
+
+func (i *Interpreter) visitIndexSetExpr(e indexSetExpr) (any, error) {
+	callee, err := i.evaluate(e.callee)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.evaluate(e.index)
+	if err != nil {
+		return nil, err
+	}
+	val, err := i.evaluate(e.value)
+	if err != nil {
+		return nil, err
+	}
+	switch callee := callee.(type) {
+	case *array:
+		indexInt, ok := index.(int)
+		if !ok {
+			return nil, NewRuntimeError(e.bracket, "Index must be an integer.")
+		}
+		pos := indexInt
+		if pos < 0 {
+			pos += callee.Len()
+		}
+		if pos < 0 || pos >= callee.Len() {
+			return nil, NewRuntimeError(e.bracket, fmt.Sprintf("Index out of range [%d] with length %d.", indexInt, callee.Len()))
+		}
+		callee.Assign(pos, val)
+	case *loxMap:
+		if i.mem != nil {
+			if _, ok, _ := callee.Get(index); !ok {
+				if err := i.alloc(mapEntrySize); err != nil {
+					return nil, NewRuntimeError(e.bracket, err.Error())
+				}
+			}
+		}
+		if err := callee.Set(index, val); err != nil {
+			return nil, NewRuntimeError(e.bracket, err.Error())
+		}
+	default:
+		return nil, NewRuntimeError(e.bracket, "Can only index arrays and maps.")
+	}
+	return val, nil
+}
+
+func (i *Interpreter) visitMapExpr(e mapExpr) (any, error) {
+	if err := i.alloc(mapSize + int64(len(e.keys))*mapEntrySize); err != nil {
+		return nil, NewRuntimeError(e.brace, err.Error())
+	}
+	out := newMap()
+	for idx, keyExpr := range e.keys {
+		key, err := i.evaluate(keyExpr)
+		if err != nil {
+			return nil, err
+		}
+		val, err := i.evaluate(e.values[idx])
+		if err != nil {
+			return nil, err
+		}
+		if err := out.Set(key, val); err != nil {
+			return nil, NewRuntimeError(e.brace, err.Error())
+		}
+	}
+	return out, nil
 }
 
 func (i *Interpreter) indexArray(bracket token, array *array, indexVal expr) (any, error) {
@@ -514,7 +810,9 @@ func (i *Interpreter) visitExprStmt(s exprStmt) error {
 }
 
 func (i *Interpreter) visitFunctionStmt(s functionStmt) error {
-	i.env.define(s.name.lexeme, newFunction(s.name, s.literal, i.env, false))
+	fn := newFunction(s.name, s.literal, i.env, false)
+	fn.file = i.file
+	i.env.define(s.name.lexeme, fn)
 	return nil
 }
 
@@ -537,7 +835,7 @@ func (i *Interpreter) visitPrintStmt(s printStmt) error {
 	if err != nil {
 		return err
 	}
-	fmt.Printf("%v\n", val)
+	fmt.Fprintf(i.stdout, "%v\n", val)
 	return nil
 }
 
@@ -556,6 +854,9 @@ func (i *Interpreter) visitReturnStmt(s returnStmt) error {
 
 func (i *Interpreter) visitWhileStmt(s whileStmt) error {
 	for {
+		if err := i.step(); err != nil {
+			return err
+		}
 		condVal, err := i.evaluate(s.condition)
 		if err != nil {
 			return err
@@ -565,27 +866,10 @@ func (i *Interpreter) visitWhileStmt(s whileStmt) error {
 		}
 		err = i.execute(s.body)
 		if err != nil {
-			var breakErr *loopBreak
-			if errors.As(err, &breakErr) {
-				if breakErr.label.lexeme != "" && breakErr.label.lexeme != s.label.lexeme {
-					return err
-				}
-				return nil
-			}
-			var contErr *loopContinue
-			if errors.As(err, &contErr) {
-				if contErr.label.lexeme != "" && contErr.label.lexeme != s.label.lexeme {
-					return err
-				}
-				if s.increment != nil {
-					err = i.execute(s.increment)
-					if err != nil {
-						return err
-					}
-				}
-				continue
+			stop, err := i.loopControl(err, s.label)
+			if err != nil || stop {
+				return err
 			}
-			return err
 		}
 		if s.increment != nil {
 			err = i.execute(s.increment)
@@ -596,6 +880,27 @@ func (i *Interpreter) visitWhileStmt(s whileStmt) error {
 	}
 }
 
+// loopControl handles an error returned from executing the body of the loop
+// with the given label. It reports whether the loop should stop because of a
+// break statement, and returns err if it must be propagated to outer loops.
+func (i *Interpreter) loopControl(err error, label token) (bool, error) {
+	var breakErr *loopBreak
+	if errors.As(err, &breakErr) {
+		if breakErr.label.lexeme != "" && breakErr.label.lexeme != label.lexeme {
+			return true, err
+		}
+		return true, nil
+	}
+	var contErr *loopContinue
+	if errors.As(err, &contErr) {
+		if contErr.label.lexeme != "" && contErr.label.lexeme != label.lexeme {
+			return true, err
+		}
+		return false, nil
+	}
+	return true, err
+}
+
 func (i *Interpreter) visitForStmt(s forStmt) error {
 	return i.executeBlock(blockStmt{[]stmt{
 		s.initializer,
@@ -603,6 +908,52 @@ func (i *Interpreter) visitForStmt(s forStmt) error {
 	}}, newEnvironment(i.env))
 }
 
+func (i *Interpreter) visitForInStmt(s forInStmt) error {
+	val, err := i.evaluate(s.iterable)
+	if err != nil {
+		return err
+	}
+	iter, ok := val.(iterable)
+	if !ok {
+		return NewRuntimeError(s.keyword, "Can only iterate over arrays, maps and generators.")
+	}
+	it := iter.iterator(i)
+	// Stopping early leaves a generator suspended at a yield on its own
+	// goroutine, so it is closed rather than kept until it is collected.
+	if g, ok := it.(*generatorIterator); ok {
+		defer g.g.close()
+	}
+	for {
+		if err := i.step(); err != nil {
+			return err
+		}
+		// Generators are resumed from the loop.
+		site := i.site
+		i.site = s.keyword
+		item, ok, err := it.next()
+		i.site = site
+		if err != nil {
+			var rtErr RuntimeError
+			if errors.As(err, &rtErr) || unwinds(err) {
+				return err
+			}
+			return NewRuntimeError(s.keyword, err.Error())
+		}
+		if !ok {
+			return nil
+		}
+		loopEnv := newEnvironment(i.env)
+		loopEnv.define(s.name.lexeme, item)
+		err = i.executeBlock(blockStmt{[]stmt{s.body}}, loopEnv)
+		if err != nil {
+			stop, err := i.loopControl(err, s.label)
+			if err != nil || stop {
+				return err
+			}
+		}
+	}
+}
+
 func (i *Interpreter) visitBreakStmt(s breakStmt) error {
 	return &loopBreak{keyword: s.keyword, label: s.label}
 }
@@ -611,6 +962,22 @@ func (i *Interpreter) visitContinueStmt(s continueStmt) error {
 	return &loopContinue{keyword: s.keyword, label: s.label}
 }
 
+func (i *Interpreter) visitYieldStmt(s yieldStmt) error {
+	if i.co == nil {
+		return NewRuntimeError(s.keyword, "Can only yield inside a generator.")
+	}
+	var val any
+	var err error
+	if s.value != nil {
+		val, err = i.evaluate(s.value)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = i.co.yield(val)
+	return err
+}
+
 func (i *Interpreter) visitBlockStmt(s blockStmt) error {
 	return i.executeBlock(s, newEnvironment(i.env))
 }
@@ -637,7 +1004,10 @@ func (i *Interpreter) visitClassStmt(s classStmt) error {
 	}
 	methods := make(map[string]*function, len(s.methods))
 	for _, m := range s.methods {
-		methods[m.name.lexeme] = newFunction(m.name, m.literal, i.env, m.name.lexeme == "init")
+		method := newFunction(m.name, m.literal, i.env, m.name.lexeme == "init")
+		method.className = s.name.lexeme
+		method.file = i.file
+		methods[m.name.lexeme] = method
 	}
 	if s.superclass != (variableExpr{}) {
 		i.env = i.env.enclosing
@@ -476,6 +476,18 @@ func Test_interpretLogicalExpr(t *testing.T) {
 			want:    nil,
 			wantErr: nil,
 		},
+		{
+			desc:    "COALESCE_leftNil",
+			input:   "nil ?? 3",
+			want:    3,
+			wantErr: nil,
+		},
+		{
+			desc:    "COALESCE_leftFalse",
+			input:   "false ?? 3",
+			want:    false,
+			wantErr: nil,
+		},
 	}
 
 	for _, tC := range testCases {
@@ -551,6 +563,24 @@ func Test_interpretCallExpr(t *testing.T) {
 			want:    nil,
 			err:     NewRuntimeError(newToken(RIGHT_PAREN, ")", nil, 1, 11), "Expected 1 arguments but got 2."),
 		},
+		{
+			desc:    "call_arrow_function",
+			input:   "add(3, 4)",
+			code:    "var add = (a, b) => a + b;",
+			initEnv: map[string]any{},
+			want:    7,
+			err:     nil,
+		},
+		{
+			desc:  "pipeline_into_call",
+			input: "3 |> add(4) |> x => x * 2",
+			code: `fn add(a, b) {
+				return a + b;
+			}`,
+			initEnv: map[string]any{},
+			want:    14,
+			err:     nil,
+		},
 		{
 			desc:  "create_instance_and_call_method",
 			input: "test().getValue()",
@@ -636,6 +666,83 @@ func Test_interpretCallExpr(t *testing.T) {
 	}
 }
 
+func Test_interpretOptionalChainExpr(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		input string
+		code  string
+		want  any
+		err   error
+	}{
+		{
+			desc:  "property_of_nil",
+			input: "empty?.value.inner",
+			code:  "var empty;",
+			want:  nil,
+		},
+		{
+			desc:  "property_of_instance",
+			input: "box?.value",
+			code: `class Box {}
+			var box = Box();
+			box.value = 42;`,
+			want: 42,
+		},
+		{
+			desc:  "method_of_nil_is_not_called",
+			input: "empty?.run()",
+			code:  "var empty;",
+			want:  nil,
+		},
+		{
+			desc:  "method_of_instance",
+			input: "box?.run()",
+			code: `class Box {
+				run() {
+					return "ran";
+				}
+			}
+			var box = Box();`,
+			want: "ran",
+		},
+		{
+			desc:  "index_of_nil",
+			input: "empty?[0]",
+			code:  "var empty;",
+			want:  nil,
+		},
+		{
+			desc:  "index_of_array",
+			input: "arr?[1]",
+			code:  "var arr = [1, 2];",
+			want:  2,
+		},
+		{
+			desc:  "non_optional_link_still_fails",
+			input: "box?.value.inner",
+			code: `class Box {}
+			var box = Box();
+			box.value = nil;`,
+			want: nil,
+			err:  NewRuntimeError(newToken(IDENTIFIER, "inner", nil, 1, 11), "Only instances have properties."),
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := runScript(t, tC.code, tC.input)
+			assert.Equal(t, tC.want, got)
+			if tC.err != nil {
+				assert.EqualError(t, err, tC.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func Test_interpretCallExpr_ClassConstructor(t *testing.T) {
 	testCases := []struct {
 		desc    string
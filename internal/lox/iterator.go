@@ -0,0 +1,27 @@
+package lox
+
+// iterable is implemented by values that can be looped over with 'for in'.
+// Iterators that wait, such as those of channels, stop waiting when the run of
+// i is interrupted.
+type iterable interface {
+	iterator(i *Interpreter) iterator
+}
+
+type iterator interface {
+	// next returns the next value, and false once there are no more values.
+	next() (any, bool, error)
+}
+
+type arrayIterator struct {
+	array *array
+	idx   int
+}
+
+func (it *arrayIterator) next() (any, bool, error) {
+	if it.idx >= it.array.Len() {
+		return nil, false, nil
+	}
+	val := it.array.Get(it.idx)
+	it.idx++
+	return val, true, nil
+}
@@ -0,0 +1,254 @@
+package lox
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// jsonDecode reads the next JSON value from dec. Objects become maps with their
+// keys in document order, and numbers become ints unless they have a fraction
+// or an exponent.
+func jsonDecode(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '[':
+			out := newArray()
+			for dec.More() {
+				val, err := jsonDecode(dec)
+				if err != nil {
+					return nil, err
+				}
+				out.Append(val)
+			}
+			_, err := dec.Token()
+			return out, err
+		case '{':
+			out := newMap()
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				val, err := jsonDecode(dec)
+				if err != nil {
+					return nil, err
+				}
+				out.Set(key, val)
+			}
+			_, err := dec.Token()
+			return out, err
+		}
+		return nil, fmt.Errorf("unexpected '%v'", t)
+	case json.Number:
+		if !strings.ContainsAny(t.String(), ".eE") {
+			if n, err := strconv.Atoi(t.String()); err == nil {
+				return n, nil
+			}
+		}
+		return t.Float64()
+	default:
+		// Strings, booleans and null.
+		return t, nil
+	}
+}
+
+func jsonParse(s string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	val, err := jsonDecode(dec)
+	if err == nil {
+		if _, err = dec.Token(); err == nil {
+			err = errors.New("unexpected data after top-level value")
+		} else if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, builtinErrMsg(fmt.Sprintf("Invalid JSON: %v.", err))
+	}
+	return val, nil
+}
+
+// jsonEncoder writes Lox values as compact JSON. It keeps track of the arrays,
+// maps and instances being written to detect cycles.
+type jsonEncoder struct {
+	buf  bytes.Buffer
+	path []any
+}
+
+func (enc *jsonEncoder) encode(val any) error {
+	switch v := val.(type) {
+	case nil:
+		enc.buf.WriteString("null")
+	case bool:
+		enc.buf.WriteString(strconv.FormatBool(v))
+	case int:
+		enc.buf.WriteString(strconv.Itoa(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return builtinErrMsg(fmt.Sprintf("Can't stringify %v.", v))
+		}
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eE") {
+			// Keep the number a float when it is parsed back.
+			s += ".0"
+		}
+		enc.buf.WriteString(s)
+	case string:
+		enc.encodeString(v)
+	case *array:
+		return enc.nested(v, func() error {
+			enc.buf.WriteByte('[')
+			for idx, item := range v.Items() {
+				if idx > 0 {
+					enc.buf.WriteByte(',')
+				}
+				if err := enc.encode(item); err != nil {
+					return err
+				}
+			}
+			enc.buf.WriteByte(']')
+			return nil
+		})
+	case *loxMap:
+		return enc.nested(v, func() error {
+			enc.buf.WriteByte('{')
+			for idx, key := range v.Keys() {
+				name, ok := key.(string)
+				if !ok {
+					return builtinErrMsg(fmt.Sprintf("Can't stringify map key %v, keys must be strings.", key))
+				}
+				if idx > 0 {
+					enc.buf.WriteByte(',')
+				}
+				enc.encodeString(name)
+				enc.buf.WriteByte(':')
+				item, _, _ := v.Get(key)
+				if err := enc.encode(item); err != nil {
+					return err
+				}
+			}
+			enc.buf.WriteByte('}')
+			return nil
+		})
+	case *instance:
+		return enc.nested(v, func() error {
+			v.mu.RLock()
+			names := make([]string, 0, len(v.fields))
+			for name := range v.fields {
+				names = append(names, name)
+			}
+			fields := make([]any, len(names))
+			slices.Sort(names)
+			for idx, name := range names {
+				fields[idx] = v.fields[name]
+			}
+			v.mu.RUnlock()
+
+			enc.buf.WriteByte('{')
+			for idx, name := range names {
+				if idx > 0 {
+					enc.buf.WriteByte(',')
+				}
+				enc.encodeString(name)
+				enc.buf.WriteByte(':')
+				if err := enc.encode(fields[idx]); err != nil {
+					return err
+				}
+			}
+			enc.buf.WriteByte('}')
+			return nil
+		})
+	default:
+		return builtinErrMsg(fmt.Sprintf("Can't stringify %v.", v))
+	}
+	return nil
+}
+
+// nested encodes a value that may contain itself.
+func (enc *jsonEncoder) nested(val any, encode func() error) error {
+	if slices.Contains(enc.path, val) {
+		return builtinErrMsg("Can't stringify a value that contains itself.")
+	}
+	enc.path = append(enc.path, val)
+	defer func() { enc.path = enc.path[:len(enc.path)-1] }()
+	return encode()
+}
+
+func (enc *jsonEncoder) encodeString(s string) {
+	// Unlike json.Marshal, don't escape HTML characters.
+	e := json.NewEncoder(&enc.buf)
+	e.SetEscapeHTML(false)
+	e.Encode(s)
+	// Encode adds a newline after the value.
+	enc.buf.Truncate(enc.buf.Len() - 1)
+}
+
+// jsonIndent returns the indentation for json.stringify, which is either a
+// number of spaces or a string.
+func jsonIndent(val any) (string, error) {
+	switch v := val.(type) {
+	case int:
+		if v < 0 || v > 10 {
+			return "", builtinErrMsg("Indent must be between 0 and 10 spaces.")
+		}
+		return strings.Repeat(" ", v), nil
+	case string:
+		return v, nil
+	default:
+		return "", builtinErrMsg("Indent must be a number of spaces or a string.")
+	}
+}
+
+func defineJsonModule(env *environment) {
+	m := newModule("json")
+
+	m.define("parse", 1, func(i *Interpreter, args []any) (any, error) {
+		s, err := stringArg("json.parse", "Value", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return jsonParse(s)
+	})
+
+	// stringify takes an optional indent, and writes compact JSON without it.
+	m.define("stringify", -1, func(i *Interpreter, args []any) (any, error) {
+		if len(args) > 2 {
+			return nil, builtinErrMsg(fmt.Sprintf("Expected at most 2 arguments but got %d.", len(args)))
+		}
+		enc := &jsonEncoder{}
+		if err := enc.encode(args[0]); err != nil {
+			return nil, err
+		}
+		if len(args) == 1 {
+			return enc.buf.String(), nil
+		}
+		indent, err := jsonIndent(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if indent == "" {
+			return enc.buf.String(), nil
+		}
+		var out bytes.Buffer
+		json.Indent(&out, enc.buf.Bytes(), "", indent)
+		return out.String(), nil
+	})
+
+	env.define("json", m)
+}
@@ -0,0 +1,136 @@
+package lox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_json(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		code    string
+		input   string
+		want    any
+		wantErr bool
+	}{
+		{
+			desc:  "stringify_compact",
+			code:  `var data = {"name": "lox", "n": 1, "f": 2.5, "tags": ["a", nil, true, false]};`,
+			input: `json.stringify(data)`,
+			want:  `{"name":"lox","n":1,"f":2.5,"tags":["a",null,true,false]}`,
+		},
+		{
+			desc:  "stringify_indent",
+			code:  `var data = {"a": [1], "b": {}};`,
+			input: `json.stringify(data, 2)`,
+			want:  "{\n  \"a\": [\n    1\n  ],\n  \"b\": {}\n}",
+		},
+		{
+			desc:  "stringify_indent_string",
+			code:  `var data = [1];`,
+			input: `json.stringify(data, "--")`,
+			want:  "[\n--1\n]",
+		},
+		{
+			desc:  "stringify_keeps_floats",
+			code:  `var data = [2.0, 1000000000000000000000.0];`,
+			input: `json.stringify(data)`,
+			want:  `[2.0,1e+21]`,
+		},
+		{
+			desc:  "stringify_does_not_escape_html",
+			code:  `var data = "<a & b>";`,
+			input: `json.stringify(data)`,
+			want:  `"<a & b>"`,
+		},
+		{
+			desc: "stringify_instance_fields",
+			code: `class Point {
+				init(x, y) {
+					this.y = y;
+					this.x = x;
+				}
+				sum() { return this.x + this.y; }
+			}
+			var p = Point(1, 2);`,
+			input: `json.stringify(p)`,
+			want:  `{"x":1,"y":2}`,
+		},
+		{
+			desc:    "stringify_function",
+			code:    `fn f() {}`,
+			input:   `json.stringify([f])`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc: "stringify_cycle",
+			code: `var m = {};
+			m["self"] = m;`,
+			input:   `json.stringify(m)`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc: "stringify_shared_value_is_not_a_cycle",
+			code: `var shared = [1];
+			var data = [shared, shared];`,
+			input: `json.stringify(data)`,
+			want:  `[[1],[1]]`,
+		},
+		{
+			desc:    "stringify_non_string_key",
+			code:    `var m = {1: "one"};`,
+			input:   `json.stringify(m)`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:  "parse_round_trip",
+			code:  `var s = json.stringify({"a": [1, 2.5, "x", nil, true], "b": {"c": 3.0}});`,
+			input: `json.stringify(json.parse(s)) == s`,
+			want:  true,
+		},
+		{
+			desc:  "parse_int_and_float",
+			code:  `var data = json.parse(json.stringify([3, 3.0, 100.0]));`,
+			input: `join(map(data, x => x / 2), ",")`,
+			want:  "1,1.5,50",
+		},
+		{
+			desc:  "parse_object_key_order",
+			code:  `var m = json.parse(json.stringify({"z": 1, "a": 2}));`,
+			input: `join(keys(m), ",")`,
+			want:  "z,a",
+		},
+		{
+			desc:    "parse_invalid",
+			code:    `var s = "[1,";`,
+			input:   `json.parse(s)`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:    "parse_trailing_data",
+			code:    `var s = "1 2";`,
+			input:   `json.parse(s)`,
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := runScript(t, tC.code, tC.input)
+			assert.Equal(t, tC.want, got)
+			if tC.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package lox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrStepLimit is the cause of the InterruptError returned when a script runs
+// more steps than allowed by WithStepLimit.
+var ErrStepLimit = errors.New("step limit exceeded")
+
+// InterruptError is returned when a script is stopped because its context is
+// done or it ran out of steps. Unlike a RuntimeError, it is not reported and
+// unwinds the whole script, including spawned tasks and timers. Err is the
+// cause, such as context.DeadlineExceeded or ErrStepLimit.
+type InterruptError struct {
+	Err error
+}
+
+func (e *InterruptError) Error() string {
+	return fmt.Sprintf("Execution interrupted: %v.", e.Err)
+}
+
+func (e *InterruptError) Unwrap() error {
+	return e.Err
+}
+
+// budget limits the steps taken or the bytes allocated by an interpreter and
+// its forks during a run.
+type budget struct {
+	limit int64
+	used  atomic.Int64
+}
+
+func newBudget(limit int64) *budget {
+	return &budget{limit: limit}
+}
+
+// spend uses n more of the budget, and reports false if it is exceeded. A nil
+// budget is unlimited.
+func (b *budget) spend(n int64) bool {
+	return b == nil || b.used.Add(n) <= b.limit
+}
+
+func (b *budget) reset() {
+	if b != nil {
+		b.used.Store(0)
+	}
+}
+
+// start sets the context of the next run and resets its budgets. Forks of the
+// interpreter made from now on stop with it.
+func (i *Interpreter) start(ctx context.Context) {
+	i.ctx = ctx
+	i.steps.reset()
+	i.mem.reset()
+}
+
+// interrupted returns an InterruptError if the context of the run is done.
+func (i *Interpreter) interrupted() error {
+	if done := i.ctx.Done(); done != nil {
+		select {
+		case <-done:
+			return i.interruption()
+		default:
+		}
+	}
+	return nil
+}
+
+// interruption returns the InterruptError that stops the run once its context
+// is done. Natives that wait also wait on i.ctx.Done(), and return it if the
+// context is done first.
+func (i *Interpreter) interruption() error {
+	return &InterruptError{Err: context.Cause(i.ctx)}
+}
+
+// step takes a step, and returns an InterruptError if the context of the run
+// is done or the step budget is spent.
+func (i *Interpreter) step() error {
+	if err := i.interrupted(); err != nil {
+		return err
+	}
+	if !i.steps.spend(1) {
+		return &InterruptError{Err: ErrStepLimit}
+	}
+	return nil
+}
+
+// Approximate sizes in bytes of the values counted by the memory limit. Values
+// are stored in interfaces, so each item of an array or entry of a map takes
+// at least one.
+const (
+	valueSize    = 16
+	stringSize   = 16
+	arraySize    = 48
+	mapSize      = 64
+	mapEntrySize = 3 * valueSize
+	instanceSize = 96
+)
+
+// sizeOf returns the approximate size of a value that was just created. The
+// items of arrays and maps are counted when they are created themselves.
+func sizeOf(val any) int64 {
+	switch v := val.(type) {
+	case string:
+		return stringSize + int64(len(v))
+	case *array:
+		return arraySize + int64(v.Len())*valueSize
+	case *loxMap:
+		return mapSize + int64(v.Len())*mapEntrySize
+	case *instance:
+		return instanceSize
+	}
+	return 0
+}
+
+// alloc accounts for n bytes allocated by the script, and returns an error if
+// they exceed the memory limit.
+func (i *Interpreter) alloc(n int64) error {
+	if !i.mem.spend(n) {
+		return builtinErrMsg("Memory limit exceeded.")
+	}
+	return nil
+}
+
+// unwinds reports whether err stops the whole script, so that it must be
+// passed on as is rather than turned into a RuntimeError.
+func unwinds(err error) bool {
+	var exit *scriptExit
+	var intr *InterruptError
+	return errors.As(err, &exit) || errors.As(err, &intr)
+}
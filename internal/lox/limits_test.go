@@ -0,0 +1,392 @@
+package lox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_limits(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		code       string
+		steps      int64
+		timeout    time.Duration
+		wantCause  error
+		wantStdout string
+	}{
+		{
+			desc:      "infinite_loop_timeout",
+			code:      `while true {}`,
+			timeout:   20 * time.Millisecond,
+			wantCause: context.DeadlineExceeded,
+		},
+		{
+			desc:      "infinite_loop_steps",
+			code:      `var n = 0; while true { n = n + 1; }`,
+			steps:     100,
+			wantCause: ErrStepLimit,
+		},
+		{
+			desc:      "infinite_recursion_steps",
+			code:      `fn f() { f(); } f();`,
+			steps:     50,
+			wantCause: ErrStepLimit,
+		},
+		{
+			desc:      "for_in_steps",
+			code:      `fn gen() { while true { yield 1; } } for x in gen() {}`,
+			steps:     100,
+			wantCause: ErrStepLimit,
+		},
+		{
+			desc:      "spawned_task_timeout",
+			code:      `fn spin() { while true {} } var t = spawn spin(); t.join();`,
+			timeout:   20 * time.Millisecond,
+			wantCause: context.DeadlineExceeded,
+		},
+		{
+			desc:      "callback_steps",
+			code:      `map([1, 2, 3], fn(x) { while true {} });`,
+			steps:     100,
+			wantCause: ErrStepLimit,
+		},
+		{
+			desc: "timer_timeout",
+			code: `print "start";
+			setTimeout(fn() { print "late"; }, 10000);`,
+			timeout:    20 * time.Millisecond,
+			wantCause:  context.DeadlineExceeded,
+			wantStdout: "start\n",
+		},
+		{
+			desc:      "join_timeout",
+			code:      `var wg = waitGroup(); wg.add(1); var t = spawn wg.wait(); t.join();`,
+			timeout:   20 * time.Millisecond,
+			wantCause: context.DeadlineExceeded,
+		},
+		{
+			desc:      "wait_group_timeout",
+			code:      `var wg = waitGroup(); wg.add(1); wg.wait();`,
+			timeout:   20 * time.Millisecond,
+			wantCause: context.DeadlineExceeded,
+		},
+		{
+			desc:      "recv_timeout",
+			code:      `var ch = channel(0); ch.recv();`,
+			timeout:   20 * time.Millisecond,
+			wantCause: context.DeadlineExceeded,
+		},
+		{
+			desc:      "send_timeout",
+			code:      `var ch = channel(0); ch.send(1);`,
+			timeout:   20 * time.Millisecond,
+			wantCause: context.DeadlineExceeded,
+		},
+		{
+			desc:      "channel_for_in_timeout",
+			code:      `var ch = channel(0); for x in ch {}`,
+			timeout:   20 * time.Millisecond,
+			wantCause: context.DeadlineExceeded,
+		},
+		{
+			desc:      "select_timeout",
+			code:      `select([channel(0), channel(0)]);`,
+			timeout:   20 * time.Millisecond,
+			wantCause: context.DeadlineExceeded,
+		},
+		{
+			desc:      "exec_timeout",
+			code:      `exec("sleep", ["10"]);`,
+			timeout:   20 * time.Millisecond,
+			wantCause: context.DeadlineExceeded,
+		},
+		{
+			desc:      "process_wait_timeout",
+			code:      `startProcess("sleep", ["10"]).wait();`,
+			timeout:   20 * time.Millisecond,
+			wantCause: context.DeadlineExceeded,
+		},
+		{
+			desc: "within_budget",
+			code: `var n = 0;
+			for var i = 0; i < 10; i = i + 1 { n = n + 1; }
+			print n;`,
+			steps:      100,
+			wantStdout: "10\n",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+			var stdout, stderr strings.Builder
+			opts := []RuntimeOption{WithStdout(&stdout), WithStderr(&stderr)}
+			if tC.steps > 0 {
+				opts = append(opts, WithStepLimit(tC.steps))
+			}
+			if tC.timeout > 0 {
+				ctx, cancel := context.WithTimeout(context.Background(), tC.timeout)
+				defer cancel()
+				opts = append(opts, WithContext(ctx))
+			}
+			er := NewLoxErrorReporter()
+			interpreter := NewInterpreter(er)
+			resolver := NewResolver(er, interpreter)
+			rt := NewRuntime(er, interpreter, resolver, opts...)
+
+			rt.run([]byte(tC.code))
+			assert.False(t, er.HadError())
+			assert.False(t, er.HadRuntimeError())
+			assert.Equal(t, tC.wantStdout, stdout.String())
+			if tC.wantCause == nil {
+				assert.Nil(t, rt.interrupt)
+				return
+			}
+			if assert.NotNil(t, rt.interrupt) {
+				assert.ErrorIs(t, rt.interrupt, tC.wantCause)
+				assert.Equal(t, rt.interrupt.Error()+"\n", stderr.String())
+			}
+		})
+	}
+}
+
+func Test_maxDepth(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		code       string
+		maxDepth   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			desc: "unbounded_recursion",
+			code: `fn f(n) {
+				return f(n + 1);
+			}
+			f(0);`,
+			wantStderr: "[line 2] Runtime Error at ')': Stack overflow.\n",
+		},
+		{
+			desc: "within_limit",
+			code: `fn sum(n) { if n == 0 { return 0; } return n + sum(n - 1); }
+			print sum(100);`,
+			maxDepth:   101,
+			wantStdout: "5050\n",
+		},
+		{
+			desc: "over_limit",
+			code: `fn sum(n) { if n == 0 { return 0; } return n + sum(n - 1); }
+			print sum(100);`,
+			maxDepth:   100,
+			wantStderr: "[line 1] Runtime Error at ')': Stack overflow.\n",
+		},
+		{
+			desc: "initializer",
+			code: `class Node {
+				init(n) { this.next = Node(n + 1); }
+			}
+			Node(0);`,
+			maxDepth:   100,
+			wantStderr: "[line 2] Runtime Error at ')': Stack overflow.\n",
+		},
+		{
+			desc: "callback",
+			code: `fn f(n) { return map([n], x => f(x + 1)); }
+			f(0);`,
+			maxDepth:   100,
+			wantStderr: "[line 1] Runtime Error at ')': Stack overflow.\n",
+		},
+		{
+			desc: "recursive_callback",
+			code: `fn f(x) { return map([x], f); }
+			f(0);`,
+			maxDepth:   100,
+			wantStderr: "[line 1] Runtime Error at ')': Stack overflow.\n",
+		},
+		{
+			desc: "recursive_comparator",
+			code: `fn cmp(a, b) { sort([a, b], cmp); return 0; }
+			cmp(1, 2);`,
+			maxDepth:   100,
+			wantStderr: "[line 1] Runtime Error at ')': Stack overflow.\n",
+		},
+		{
+			desc: "recursive_replacement",
+			code: `fn repl(m) { return regex.compile("a").replace(m, repl); }
+			repl("a");`,
+			maxDepth:   100,
+			wantStderr: "[line 1] Runtime Error at ')': Stack overflow.\n",
+		},
+		{
+			desc: "generator",
+			code: `fn gen(n) { for x in gen(n + 1) { yield x; } }
+			for x in gen(0) {}`,
+			maxDepth:   100,
+			wantStderr: "[line 1] Runtime Error at ')': Stack overflow.\n",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+			var stdout, stderr strings.Builder
+			opts := []RuntimeOption{WithStdout(&stdout), WithStderr(&stderr)}
+			if tC.maxDepth > 0 {
+				opts = append(opts, WithMaxDepth(tC.maxDepth))
+			}
+			er := NewLoxErrorReporter()
+			interpreter := NewInterpreter(er)
+			resolver := NewResolver(er, interpreter)
+			rt := NewRuntime(er, interpreter, resolver, opts...)
+
+			rt.run([]byte(tC.code))
+			assert.Equal(t, tC.wantStdout, stdout.String())
+			assert.Equal(t, tC.wantStderr, firstLine(stderr.String()))
+
+			// Like the REPL, the runtime can still be used after the error.
+			stdout.Reset()
+			er.ResetRuntimeError()
+			rt.run([]byte(`fn g(n) { if n > 0 { return g(n - 1); } return "ok"; } print g(50);`))
+			assert.False(t, er.HadRuntimeError())
+			assert.Equal(t, "ok\n", stdout.String())
+		})
+	}
+}
+
+func Test_memoryLimit(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		code       string
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			desc: "string_concat",
+			code: `var s = "ab";
+			while true { s = s + s; }`,
+			wantStderr: "[line 2] Runtime Error at '+': Memory limit exceeded.\n",
+		},
+		{
+			desc: "append",
+			code: `var arr = [];
+			while true { append(arr, 1, 2, 3); }`,
+			wantStderr: "[line 2] Runtime Error at ')': Memory limit exceeded.\n",
+		},
+		{
+			desc: "array_literals",
+			code: `var all = [];
+			while true { all = [all, all]; }`,
+			wantStderr: "[line 2] Runtime Error at '[': Memory limit exceeded.\n",
+		},
+		{
+			desc: "map_keys",
+			code: `var m = {};
+			var n = 0;
+			while true { m[n] = n; n = n + 1; }`,
+			wantStderr: "[line 3] Runtime Error at '[': Memory limit exceeded.\n",
+		},
+		{
+			desc: "map_updates",
+			code: `var m = {"count": 0};
+			for var n = 0; n < 10000; n = n + 1 { m["count"] = n; }
+			print m["count"];`,
+			wantStdout: "9999\n",
+		},
+		{
+			desc: "instances",
+			code: `class Node { init(next) { this.next = next; } }
+			var head = nil;
+			while true { head = Node(head); }`,
+			wantStderr: "[line 1] Runtime Error at 'next': Memory limit exceeded.\n",
+		},
+		{
+			desc: "native_results",
+			code: `var parts = ["abc", "def"];
+			while true { parts = [join(parts, ","), join(parts, ";")]; }`,
+			wantStderr: "[line 2] Runtime Error at ')': Memory limit exceeded.\n",
+		},
+		{
+			desc: "within_limit",
+			code: `var s = "";
+			for var n = 0; n < 5; n = n + 1 { s = s + "x"; }
+			print s;`,
+			wantStdout: "xxxxx\n",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+			var stdout, stderr strings.Builder
+			er := NewLoxErrorReporter()
+			interpreter := NewInterpreter(er)
+			resolver := NewResolver(er, interpreter)
+			rt := NewRuntime(er, interpreter, resolver,
+				WithStdout(&stdout), WithStderr(&stderr), WithMemoryLimit(1<<20))
+
+			rt.run([]byte(tC.code))
+			assert.Equal(t, tC.wantStdout, stdout.String())
+			assert.Equal(t, tC.wantStderr, firstLine(stderr.String()))
+
+			// The limit applies to each run.
+			stdout.Reset()
+			er.ResetRuntimeError()
+			rt.run([]byte(`var arr = []; for var n = 0; n < 100; n = n + 1 { append(arr, "x" + "y"); } print len(arr);`))
+			assert.False(t, er.HadRuntimeError())
+			assert.Equal(t, "100\n", stdout.String())
+		})
+	}
+}
+
+func Test_memoryLimit_instanceFields(t *testing.T) {
+	var fields strings.Builder
+	for n := range 100 {
+		fmt.Fprintf(&fields, "bag.f%d = %d;\n", n, n)
+	}
+	code := "class Bag {}\nvar bag = Bag();\n" + fields.String()
+
+	// Each new field takes a map entry, and assigning existing ones is free.
+	for _, tC := range []struct {
+		desc       string
+		code       string
+		wantStderr string
+	}{
+		{
+			desc:       "new_fields",
+			code:       code,
+			wantStderr: "[line 23] Runtime Error at 'f20': Memory limit exceeded.\n",
+		},
+		{
+			desc: "existing_fields",
+			code: `class Bag {}
+			var bag = Bag();
+			for var n = 0; n < 10000; n = n + 1 { bag.count = n; }`,
+		},
+	} {
+		t.Run(tC.desc, func(t *testing.T) {
+			var stderr strings.Builder
+			er := NewLoxErrorReporter()
+			interpreter := NewInterpreter(er)
+			resolver := NewResolver(er, interpreter)
+			rt := NewRuntime(er, interpreter, resolver, WithStderr(&stderr), WithMemoryLimit(instanceSize+20*mapEntrySize+1))
+
+			rt.run([]byte(tC.code))
+			assert.Equal(t, tC.wantStderr, firstLine(stderr.String()))
+		})
+	}
+}
+
+// firstLine returns the first line of the output of a script, which is the
+// error message when it is followed by a traceback.
+func firstLine(s string) string {
+	line, _, found := strings.Cut(s, "\n")
+	if !found {
+		return s
+	}
+	return line + "\n"
+}
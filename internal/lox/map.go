@@ -0,0 +1,160 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// loxMap is a map from strings, numbers, booleans or nil to any value. It keeps
+// its keys in insertion order, so that it prints and iterates predictably.
+type loxMap struct {
+	mu     sync.RWMutex
+	keys   []any
+	values map[any]any
+}
+
+func newMap() *loxMap {
+	return &loxMap{keys: make([]any, 0), values: make(map[any]any)}
+}
+
+// mapKey checks that key can be used in a map. Integral floats are turned into
+// ints, since they are equal in Lox.
+func mapKey(key any) (any, error) {
+	switch k := key.(type) {
+	case nil, bool, int, string:
+		return k, nil
+	case float64:
+		if k == math.Trunc(k) && math.Abs(k) < 1<<53 {
+			return int(k), nil
+		}
+		return k, nil
+	default:
+		return nil, builtinErrMsg("Map keys must be strings, numbers, booleans or nil.")
+	}
+}
+
+// Get returns the value for key, and false if there is none.
+func (m *loxMap) Get(key any) (any, bool, error) {
+	key, err := mapKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	val, ok := m.values[key]
+	return val, ok, nil
+}
+
+func (m *loxMap) Set(key, val any) error {
+	key, err := mapKey(key)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = val
+	return nil
+}
+
+// Delete removes key from the map, and reports whether it was there.
+func (m *loxMap) Delete(key any) (bool, error) {
+	key, err := mapKey(key)
+	if err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.values[key]; !ok {
+		return false, nil
+	}
+	delete(m.values, key)
+	for idx, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:idx], m.keys[idx+1:]...)
+			break
+		}
+	}
+	return true, nil
+}
+
+// Keys returns a copy of the keys, in insertion order.
+func (m *loxMap) Keys() []any {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]any{}, m.keys...)
+}
+
+func (m *loxMap) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.keys)
+}
+
+func (m *loxMap) String() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var sb strings.Builder
+	sb.WriteString("map[")
+	for idx, key := range m.keys {
+		if idx > 0 {
+			sb.WriteString(" ")
+		}
+		fmt.Fprintf(&sb, "%v:%v", key, m.values[key])
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// iterator loops over the keys of the map.
+func (m *loxMap) iterator(i *Interpreter) iterator {
+	keys := newArray()
+	keys.Append(m.Keys()...)
+	return keys.iterator(i)
+}
+
+func defineMapFns(env *environment) {
+	env.define("keys", newBuiltinFn("keys", 1, func(i *Interpreter, args []any) (any, error) {
+		m, ok := args[0].(*loxMap)
+		if !ok {
+			return nil, builtinErrMsg("Can only call 'keys' on maps.")
+		}
+		out := newArray()
+		out.Append(m.Keys()...)
+		return out, nil
+	}))
+
+	env.define("values", newBuiltinFn("values", 1, func(i *Interpreter, args []any) (any, error) {
+		m, ok := args[0].(*loxMap)
+		if !ok {
+			return nil, builtinErrMsg("Can only call 'values' on maps.")
+		}
+		out := newArray()
+		for _, key := range m.Keys() {
+			val, _, _ := m.Get(key)
+			out.Append(val)
+		}
+		return out, nil
+	}))
+
+	env.define("has", newBuiltinFn("has", 2, func(i *Interpreter, args []any) (any, error) {
+		m, ok := args[0].(*loxMap)
+		if !ok {
+			return nil, builtinErrMsg("Can only call 'has' on maps.")
+		}
+		_, ok, err := m.Get(args[1])
+		return ok, err
+	}))
+
+	env.define("delete", newBuiltinFn("delete", 2, func(i *Interpreter, args []any) (any, error) {
+		m, ok := args[0].(*loxMap)
+		if !ok {
+			return nil, builtinErrMsg("Can only call 'delete' on maps.")
+		}
+		return m.Delete(args[1])
+	}))
+}
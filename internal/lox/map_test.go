@@ -0,0 +1,103 @@
+package lox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_map(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		code    string
+		input   string
+		want    any
+		wantErr bool
+	}{
+		{
+			desc:  "literal_and_index",
+			code:  `var m = {"a": 1, 2: "two", true: nil};`,
+			input: `m["a"] + len(m)`,
+			want:  4,
+		},
+		{
+			desc:  "missing_key_is_nil",
+			code:  `var m = {"a": 1};`,
+			input: `m["b"]`,
+			want:  nil,
+		},
+		{
+			desc:  "integral_float_key_equals_int",
+			code:  `var m = {1: "one"};`,
+			input: `m[1.0]`,
+			want:  "one",
+		},
+		{
+			desc: "index_set_keeps_insertion_order",
+			code: `var m = {"b": 1};
+			m["a"] = 2;
+			m["b"] = 3;`,
+			input: `join(keys(m), ",") + " " + join(values(m), ",")`,
+			want:  "b,a 3,2",
+		},
+		{
+			desc: "has_and_delete",
+			code: `var m = {"a": 1, "b": 2};
+			var deleted = delete(m, "a");`,
+			input: `deleted and !has(m, "a") and has(m, "b") and !delete(m, "a")`,
+			want:  true,
+		},
+		{
+			desc: "iterate_keys",
+			code: `var m = {"x": 1, "y": 2};
+			var out = "";
+			for k in m {
+				out = out + k + m[k];
+			}`,
+			input: "out",
+			want:  "x1y2",
+		},
+		{
+			desc:  "print_format",
+			code:  `var m = {"a": [1, 2], "b": {}};`,
+			input: `join([m], "")`,
+			want:  "map[a:[1 2] b:map[]]",
+		},
+		{
+			desc:    "unhashable_key",
+			code:    `var m = {};`,
+			input:   `m[[1]] = 1`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc: "array_index_set",
+			code: `var arr = [1, 2, 3];
+			arr[0] = 10;
+			arr[-1] = 30;`,
+			input: `join(arr, ",")`,
+			want:  "10,2,30",
+		},
+		{
+			desc:    "array_index_set_out_of_range",
+			code:    `var arr = [1];`,
+			input:   `arr[1] = 2`,
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := runScript(t, tC.code, tC.input)
+			assert.Equal(t, tC.want, got)
+			if tC.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package lox
+
+import "fmt"
+
+// module is a namespace of native functions, such as 'fs', whose members are
+// accessed with the dot operator.
+type module struct {
+	name    string
+	members map[string]any
+}
+
+func newModule(name string) *module {
+	return &module{name: name, members: make(map[string]any)}
+}
+
+// define adds a native function to the module. It is named after the module
+// when printed, for example '<native fn fs.readFile>'.
+func (m *module) define(name string, arity int, callFn func(i *Interpreter, args []any) (any, error)) {
+	m.members[name] = newBuiltinFn(fmt.Sprintf("%s.%s", m.name, name), arity, callFn)
+}
+
+func (m *module) get(name token) (any, error) {
+	val, ok := m.members[name.lexeme]
+	if !ok {
+		return nil, NewRuntimeError(name, fmt.Sprintf("Undefined properties '%s'", name.lexeme))
+	}
+	return val, nil
+}
+
+func (m *module) String() string {
+	return fmt.Sprintf("<module %s>", m.name)
+}
@@ -0,0 +1,13 @@
+package lox
+
+// object is implemented by values whose properties can be accessed with the
+// dot operator.
+type object interface {
+	get(name token) (any, error)
+}
+
+// fieldSetter is implemented by objects other than instances whose properties
+// can be assigned.
+type fieldSetter interface {
+	set(name token, val any) error
+}
@@ -0,0 +1,24 @@
+package lox
+
+import (
+	"io"
+	"sync"
+)
+
+// syncWriter is the standard output of scripts. It is shared by forked
+// interpreters, so writes are serialized and the output of two print
+// statements is never interleaved.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func newSyncWriter(w io.Writer) *syncWriter {
+	return &syncWriter{w: w}
+}
+
+func (sw *syncWriter) Write(p []byte) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Write(p)
+}
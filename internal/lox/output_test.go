@@ -0,0 +1,69 @@
+package lox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_output(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		code       string
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			desc:       "print",
+			code:       `print 1 + 2; print "three";`,
+			wantStdout: "3\nthree\n",
+		},
+		{
+			desc:       "printf_println",
+			code:       `printf("{}-", 1); println("a", 2, true); println();`,
+			wantStdout: "1-a 2 true\n\n",
+		},
+		{
+			desc:       "spawned_output",
+			code:       `fn task() { print "task"; } var t = spawn task(); t.join();`,
+			wantStdout: "task\n",
+		},
+		{
+			desc:       "runtime_error",
+			code:       `print "before"; print 1 - "one"; print "after";`,
+			wantStdout: "before\nafter\n",
+			wantStderr: `[line 1] Runtime Error at '-': Operands must be numbers.
+ --> 1:25
+  |
+1 | print "before"; print 1 - "one"; print "after";
+  |                         ^
+`,
+		},
+		{
+			desc: "parse_error",
+			code: `print "never"; print;`,
+			wantStderr: `[line 1] Error at ';': Expect an expression.
+ --> 1:21
+  |
+1 | print "never"; print;
+  |                     ^
+`,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+			var stdout, stderr strings.Builder
+			er := NewLoxErrorReporter()
+			interpreter := NewInterpreter(er)
+			resolver := NewResolver(er, interpreter)
+			rt := NewRuntime(er, interpreter, resolver, WithStdout(&stdout), WithStderr(&stderr))
+
+			rt.run([]byte(tC.code))
+			assert.Equal(t, tC.wantStdout, stdout.String())
+			assert.Equal(t, tC.wantStderr, stderr.String())
+		})
+	}
+}
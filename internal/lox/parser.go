@@ -9,6 +9,9 @@ type Parser struct {
 	er      ErrorReporter
 	tokens  []token
 	current int
+	// yielded records whether a yield statement was parsed in the body of the
+	// function currently being parsed, which makes that function a generator.
+	yielded bool
 }
 
 func NewParser(er ErrorReporter, tokens []token) *Parser {
@@ -32,7 +35,7 @@ func (p *Parser) Parse() ([]stmt, error) {
 	return out, nil
 }
 
-// declaration → classDecl | fnDecl | varDecl | statement ;
+// declaration → classDecl | fnDecl | asyncFnDecl | varDecl | statement ;
 func (p *Parser) declaration() (out stmt, err error) {
 	switch {
 	case p.match(CLASS):
@@ -41,6 +44,8 @@ func (p *Parser) declaration() (out stmt, err error) {
 		out, err = p.varDecl()
 	case p.match(FN):
 		out, err = p.function(fnTypeFUNCTION)
+	case p.match(ASYNC) && p.matchNext(FN):
+		out, err = p.asyncFunction(fnTypeFUNCTION)
 	default:
 		out, err = p.statement()
 	}
@@ -52,7 +57,7 @@ func (p *Parser) declaration() (out stmt, err error) {
 	return out, nil
 }
 
-// classDecl → "class" IDENTIFIER ( "<" IDENTIFIER )? "{" function* "}" ;
+// classDecl → "class" IDENTIFIER ( "<" IDENTIFIER )? "{" ( "async"? function )* "}" ;
 func (p *Parser) classDecl() (stmt, error) {
 	_, err := p.consume(CLASS, "Expect 'class' at the beginning of variable declaration.")
 	if err != nil {
@@ -77,7 +82,12 @@ func (p *Parser) classDecl() (stmt, error) {
 	}
 	methods := make([]functionStmt, 0)
 	for !p.match(RIGHT_BRACE) && !p.isAtEnd() {
-		method, err := p.function(fnTypeMETHOD)
+		var method functionStmt
+		if p.match(ASYNC) {
+			method, err = p.asyncFunction(fnTypeMETHOD)
+		} else {
+			method, err = p.function(fnTypeMETHOD)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -114,6 +124,20 @@ func (p *Parser) function(ft fnType) (functionStmt, error) {
 	return functionStmt{name: name, literal: fnLiteral}, nil
 }
 
+// asyncFnDecl → "async" fnDecl ;
+func (p *Parser) asyncFunction(ft fnType) (functionStmt, error) {
+	_, err := p.consume(ASYNC, "Expect 'async' at the beginning of async function declaration.")
+	if err != nil {
+		return functionStmt{}, err
+	}
+	out, err := p.function(ft)
+	if err != nil {
+		return functionStmt{}, err
+	}
+	out.literal.isAsync = true
+	return out, nil
+}
+
 // varDecl → "var" IDENTIFIER ( "=" expression )? ";" ;
 func (p *Parser) varDecl() (stmt, error) {
 	_, err := p.consume(VAR, "Expect 'var' at the beginning of variable declaration.")
@@ -139,8 +163,8 @@ func (p *Parser) varDecl() (stmt, error) {
 }
 
 /*
-statement → exprStmt | forStmt | ifStmt | printStmt | returnStmt | whileStmt
-| breakStmt | continueStmt | block ;
+statement → exprStmt | forStmt | forInStmt | ifStmt | printStmt | returnStmt
+| whileStmt | breakStmt | continueStmt | yieldStmt | block ;
 */
 func (p *Parser) statement() (stmt, error) {
 	switch {
@@ -158,6 +182,8 @@ func (p *Parser) statement() (stmt, error) {
 		return p.breakStatement()
 	case p.match(CONTINUE):
 		return p.continueStatement()
+	case p.match(YIELD):
+		return p.yieldStatement()
 	case p.match(LEFT_BRACE):
 		stmts, err := p.block()
 		if err != nil {
@@ -184,7 +210,7 @@ func (p *Parser) exprStatement() (stmt, error) {
 	return exprStmt{expr: expr}, nil
 }
 
-// labeledLoopStmt → IDENTIFIER ":" ( forStmt | whileStmt ) ;
+// labeledLoopStmt → IDENTIFIER ":" ( forStmt | forInStmt | whileStmt ) ;
 func (p *Parser) labeledLoopStatement(labelExpr expr) (stmt, error) {
 	tok, _ := p.advance()
 	var label variableExpr
@@ -200,12 +226,16 @@ func (p *Parser) labeledLoopStatement(labelExpr expr) (stmt, error) {
 		if err != nil {
 			return nil, err
 		}
-		forLoop, ok := loop.(forStmt)
-		if !ok {
+		switch forLoop := loop.(type) {
+		case forStmt:
+			forLoop.whileBody.label = label.name
+			return forLoop, nil
+		case forInStmt:
+			forLoop.label = label.name
+			return forLoop, nil
+		default:
 			return nil, p.er.ParseError(tok, "Expect for loop statement.")
 		}
-		forLoop.whileBody.label = label.name
-		return forLoop, nil
 	case p.match(WHILE):
 		loop, err = p.whileStatement()
 		if err != nil {
@@ -225,9 +255,13 @@ func (p *Parser) labeledLoopStatement(labelExpr expr) (stmt, error) {
 // forStmt → "for" ( varDecl | exprStmt | ";" ) expression? ";" expression? block ;
 func (p *Parser) forStatement() (stmt, error) {
 	var err error
-	if _, err := p.consume(FOR, "Expect loop."); err != nil {
+	keyword, err := p.consume(FOR, "Expect loop.")
+	if err != nil {
 		return nil, err
 	}
+	if p.match(IDENTIFIER) && p.matchNext(IN) {
+		return p.forInStatement(keyword)
+	}
 
 	var initializer stmt
 	if p.match(VAR) {
@@ -283,6 +317,31 @@ func (p *Parser) forStatement() (stmt, error) {
 	return out, nil
 }
 
+// forInStmt → "for" IDENTIFIER "in" expression block ;
+func (p *Parser) forInStatement(keyword token) (stmt, error) {
+	name, err := p.consume(IDENTIFIER, "Expect loop variable name.")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume(IN, "Expect 'in' after loop variable."); err != nil {
+		return nil, err
+	}
+	iterable, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	bodyStmts, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	return forInStmt{
+		keyword:  keyword,
+		name:     name,
+		iterable: iterable,
+		body:     blockStmt{bodyStmts},
+	}, nil
+}
+
 // ifStmt → "if" expression block ( "else" block )? ;
 func (p *Parser) ifStatement() (stmt, error) {
 	if _, err := p.consume(IF, "Expect if statement."); err != nil {
@@ -402,6 +461,26 @@ func (p *Parser) continueStatement() (stmt, error) {
 	return continueStmt{keyword: tok, label: label}, nil
 }
 
+// yieldStmt → "yield" expression? ";" ;
+func (p *Parser) yieldStatement() (stmt, error) {
+	tok, err := p.consume(YIELD, "Expect yield statement.")
+	if err != nil {
+		return nil, err
+	}
+	var value expr
+	if !p.match(SEMICOLON) {
+		value, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.consume(SEMICOLON, "Expect ';' after yield value."); err != nil {
+		return nil, err
+	}
+	p.yielded = true
+	return yieldStmt{keyword: tok, value: value}, nil
+}
+
 // block → "{" declaration* "}" ;
 func (p *Parser) block() ([]stmt, error) {
 	if _, err := p.consume(LEFT_BRACE, "Expect block."); err != nil {
@@ -442,9 +521,9 @@ func (p *Parser) expression() (expr, error) {
 	return out, nil
 }
 
-// assignment → ( call "." )? IDENTIFIER "=" assignment | logic_or ;
+// assignment → ( call "." )? IDENTIFIER "=" assignment | call "[" expression "]" "=" assignment | pipeline ;
 func (p *Parser) assignment() (expr, error) {
-	out, err := p.or()
+	out, err := p.pipeline()
 	if err != nil {
 		return nil, err
 	}
@@ -456,8 +535,10 @@ func (p *Parser) assignment() (expr, error) {
 		}
 		if varExpr, ok := out.(variableExpr); ok {
 			out = assignExpr{name: varExpr.name, value: val}
-		} else if getExpr, ok := out.(getExpr); ok {
+		} else if getExpr, ok := out.(getExpr); ok && !getExpr.optional {
 			out = setExpr{object: getExpr.object, name: getExpr.name, value: val}
+		} else if indexExpr, ok := out.(indexExpr); ok && !indexExpr.optional {
+			out = indexSetExpr{callee: indexExpr.callee, bracket: indexExpr.bracket, index: indexExpr.index, value: val}
 		} else {
 			return nil, p.er.ParseError(tok, "Invalid assignment target.")
 		}
@@ -465,6 +546,53 @@ func (p *Parser) assignment() (expr, error) {
 	return out, nil
 }
 
+// pipeline → coalesce ( "|>" coalesce )* ;
+//
+// The value on the left is passed as the first argument to the call on the
+// right, so 'x |> f(a)' is 'f(x, a)'. Anything else on the right is called with
+// the value as its only argument, so 'x |> f' is 'f(x)'.
+func (p *Parser) pipeline() (expr, error) {
+	out, err := p.coalesce()
+	if err != nil {
+		return nil, err
+	}
+	for p.match(PIPE_GREATER) {
+		tok, _ := p.advance()
+		right, err := p.coalesce()
+		if err != nil {
+			return nil, err
+		}
+		call, ok := right.(callExpr)
+		if !ok {
+			out = callExpr{callee: right, paren: tok, arguments: []expr{out}}
+			continue
+		}
+		if len(call.arguments) >= 255 {
+			return nil, p.er.ParseError(call.paren, "Can't have more than 255 arguments.")
+		}
+		args := append([]expr{out}, call.arguments...)
+		out = callExpr{callee: call.callee, paren: call.paren, arguments: args}
+	}
+	return out, nil
+}
+
+// coalesce → logic_or ( "??" logic_or )* ;
+func (p *Parser) coalesce() (expr, error) {
+	out, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	for p.match(QUESTION_QUESTION) {
+		tok, _ := p.advance()
+		right, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		out = logicalExpr{left: out, operator: tok, right: right}
+	}
+	return out, nil
+}
+
 // logic_or → logic_and ( "or" logic_and )* ;
 func (p *Parser) or() (expr, error) {
 	out, err := p.and()
@@ -605,7 +733,7 @@ func (p *Parser) factor() (expr, error) {
 	return out, nil
 }
 
-// unary → ( "!" | "-" ) unary | call ;
+// unary → ( "!" | "-" ) unary | "await" unary | spawn | call ;
 func (p *Parser) unary() (expr, error) {
 	if p.match(BANG, MINUS) {
 		oper, _ := p.advance()
@@ -615,13 +743,41 @@ func (p *Parser) unary() (expr, error) {
 		}
 		return unaryExpr{operator: oper, right: next}, nil
 	}
+	if p.match(AWAIT) {
+		keyword, _ := p.advance()
+		value, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return awaitExpr{keyword: keyword, value: value}, nil
+	}
+	if p.match(SPAWN) {
+		return p.spawn()
+	}
 	return p.call()
 }
 
+// spawn → "spawn" call ;
+func (p *Parser) spawn() (expr, error) {
+	tok, err := p.consume(SPAWN, "Expect 'spawn'.")
+	if err != nil {
+		return nil, err
+	}
+	out, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+	call, ok := out.(callExpr)
+	if !ok {
+		return nil, p.er.ParseError(tok, "Expect function call after 'spawn'.")
+	}
+	return spawnExpr{keyword: tok, call: call}, nil
+}
+
 /*
 primary → "true" | "false" | "nil" | "this"
 | NUMBER | STRING | IDENTIFIER | "(" expression ")"
-| "super" "." IDENTIFIER | "fn" "(" parameters? ")" block ;
+| "super" "." IDENTIFIER | "async"? "fn" "(" parameters? ")" block ;
 */
 func (p *Parser) primary() (expr, error) {
 	tok, err := p.advance()
@@ -640,8 +796,18 @@ func (p *Parser) primary() (expr, error) {
 	case tok.hasType(NUMBER, STRING):
 		return literalExpr{tok.literal}, nil
 	case tok.hasType(IDENTIFIER):
+		if p.match(EQUAL_GREATER) {
+			return p.arrowFunction([]token{tok})
+		}
 		return variableExpr{tok}, nil
 	case tok.hasType(LEFT_PAREN):
+		if p.isArrowParams() {
+			params, err := p.parameters()
+			if err != nil {
+				return nil, err
+			}
+			return p.arrowFunction(params)
+		}
 		out, err := p.expression()
 		if err != nil {
 			return nil, err
@@ -662,8 +828,20 @@ func (p *Parser) primary() (expr, error) {
 		return superExpr{keyword: tok, method: method}, nil
 	case tok.hasType(FN):
 		return p.functionLiteral(fnTypeANONYMOUS)
+	case tok.hasType(ASYNC):
+		if _, err := p.consume(FN, "Expect 'fn' after 'async'."); err != nil {
+			return nil, err
+		}
+		out, err := p.functionLiteral(fnTypeANONYMOUS)
+		if err != nil {
+			return nil, err
+		}
+		out.isAsync = true
+		return out, nil
 	case tok.hasType(LEFT_BRACKET):
-		return p.arrayLiteral()
+		return p.arrayLiteral(tok)
+	case tok.hasType(LEFT_BRACE):
+		return p.mapLiteral(tok)
 	case tok.hasType(SLASH, STAR, MINUS, PLUS, GREATER, GREATER_EQUAL, LESS, LESS_EQUAL, BANG, BANG_EQUAL):
 		_, err := p.expression()
 		if err != nil {
@@ -675,13 +853,14 @@ func (p *Parser) primary() (expr, error) {
 	}
 }
 
-// call → primary ( "(" arguments? ")" | "." IDENTIFIER )* ;
+// call → primary ( "(" arguments? ")" | "?"? "[" expression "]" | ( "." | "?." ) IDENTIFIER )* ;
 // arguments → expression ( "," expression )* ;
 func (p *Parser) call() (expr, error) {
 	out, err := p.primary()
 	if err != nil {
 		return nil, err
 	}
+	var optional bool
 	for {
 		if p.match(LEFT_PAREN) {
 			out, err = p.finishCall(out)
@@ -693,22 +872,49 @@ func (p *Parser) call() (expr, error) {
 			if err != nil {
 				return nil, err
 			}
-		} else if p.match(DOT) {
-			p.advance()
-			name, err := p.consume(IDENTIFIER, "Expect property name after '.'.")
+		} else if p.isOptionalIndex() {
+			p.advance() // consume '?'
+			out, err = p.index(out)
+			if err != nil {
+				return nil, err
+			}
+			index := out.(indexExpr)
+			index.optional = true
+			out = index
+			optional = true
+		} else if p.match(DOT, QUESTION_DOT) {
+			tok, _ := p.advance()
+			name, err := p.consume(IDENTIFIER, fmt.Sprintf("Expect property name after '%s'.", tok.lexeme))
 			if err != nil {
 				return nil, err
 			}
-			out = getExpr{object: out, name: name}
+			out = getExpr{object: out, name: name, optional: tok.hasType(QUESTION_DOT)}
+			optional = optional || tok.hasType(QUESTION_DOT)
 		} else {
 			break
 		}
 	}
+	if optional {
+		// The whole chain evaluates to nil as soon as one of its optional links
+		// is nil.
+		out = optionalChainExpr{chain: out}
+	}
 	return out, nil
 }
 
+// isOptionalIndex reports whether the current '?' starts an optional index,
+// such as 'arr?[i]', which it does if it is immediately followed by '['. A
+// ternary expression whose then branch starts with an array literal needs a
+// space after the '?', as in 'c ? [1, 2][0] : 3'.
+func (p *Parser) isOptionalIndex() bool {
+	if !p.match(QUESTION) || !p.matchNext(LEFT_BRACKET) {
+		return false
+	}
+	return p.tokens[p.current+1].offset == p.tokens[p.current].offset+1
+}
+
 func (p *Parser) finishCall(callee expr) (expr, error) {
-	tok, err := p.consume(LEFT_PAREN, "Expect '(' at call.")
+	_, err := p.consume(LEFT_PAREN, "Expect '(' at call.")
 	if err != nil {
 		return nil, err
 	}
@@ -733,11 +939,11 @@ func (p *Parser) finishCall(callee expr) (expr, error) {
 			}
 		}
 	}
-	_, err = p.consume(RIGHT_PAREN, "Expect ')' after arguments.")
+	paren, err := p.consume(RIGHT_PAREN, "Expect ')' after arguments.")
 	if err != nil {
 		return nil, err
 	}
-	return callExpr{callee: callee, paren: tok, arguments: args}, nil
+	return callExpr{callee: callee, paren: paren, arguments: args}, nil
 }
 
 // functionLiteral → "fn" "(" parameters? ")" block ;
@@ -753,15 +959,35 @@ func (p *Parser) functionLiteral(ft fnType) (functionExpr, error) {
 	if err != nil {
 		return functionExpr{}, err
 	}
+	parameters, err := p.parameters()
+	if err != nil {
+		return functionExpr{}, err
+	}
+	enclosingYielded := p.yielded
+	p.yielded = false
+	defer func(p *Parser) {
+		p.yielded = enclosingYielded
+	}(p)
+	bodyStmts, err := p.block()
+	if err != nil {
+		return functionExpr{}, err
+	}
+	return functionExpr{params: parameters, body: bodyStmts, isGenerator: p.yielded}, nil
+}
+
+// parameters → IDENTIFIER ( "," IDENTIFIER )* ;
+//
+// parameters parses an optional parameter list and the closing ')'.
+func (p *Parser) parameters() ([]token, error) {
 	parameters := make([]token, 0)
 	if !p.match(RIGHT_PAREN) {
 		for {
 			if len(parameters) >= 255 {
-				return functionExpr{}, p.er.ParseError(p.peek(), "Can't have more than 255 parameters.")
+				return nil, p.er.ParseError(p.peek(), "Can't have more than 255 parameters.")
 			}
 			param, err := p.consume(IDENTIFIER, "Expect parameter name.")
 			if err != nil {
-				return functionExpr{}, err
+				return nil, err
 			}
 			parameters = append(parameters, param)
 			if p.match(COMMA) {
@@ -771,20 +997,80 @@ func (p *Parser) functionLiteral(ft fnType) (functionExpr, error) {
 			}
 		}
 	}
-	_, err = p.consume(RIGHT_PAREN, "Expect ')' after parameters.")
+	_, err := p.consume(RIGHT_PAREN, "Expect ')' after parameters.")
+	if err != nil {
+		return nil, err
+	}
+	return parameters, nil
+}
+
+// arrowFunction → ( IDENTIFIER | "(" parameters? ")" ) "=>" assignment ;
+//
+// The parameters have already been consumed. The body expression desugars to a
+// function literal that returns it, so 'x => x * 2' is 'fn (x) { return x * 2; }'.
+func (p *Parser) arrowFunction(params []token) (functionExpr, error) {
+	arrow, err := p.consume(EQUAL_GREATER, "Expect '=>' after parameters.")
 	if err != nil {
 		return functionExpr{}, err
 	}
-	bodyStmts, err := p.block()
+	body, err := p.assignment()
 	if err != nil {
 		return functionExpr{}, err
 	}
-	return functionExpr{params: parameters, body: bodyStmts}, nil
+	return functionExpr{params: params, body: []stmt{returnStmt{keyword: arrow, value: body}}}, nil
+}
+
+// isArrowParams reports whether the tokens after the current '(' are the
+// parameter list of an arrow function rather than a grouping expression.
+func (p *Parser) isArrowParams() bool {
+	for idx := p.current; idx < len(p.tokens); idx++ {
+		switch p.tokens[idx].tokenType {
+		case IDENTIFIER, COMMA:
+		case RIGHT_PAREN:
+			return idx+1 < len(p.tokens) && p.tokens[idx+1].hasType(EQUAL_GREATER)
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// mapLiteral → "{" mapEntries "}" ;
+// mapEntries → assignment ":" assignment ( "," assignment ":" assignment )* ;
+func (p *Parser) mapLiteral(brace token) (mapExpr, error) {
+	out := mapExpr{brace: brace, keys: make([]expr, 0), values: make([]expr, 0)}
+	for !p.match(RIGHT_BRACE) {
+		if len(out.keys) >= 255 {
+			return mapExpr{}, p.er.ParseError(p.peek(), "Can't have more than 255 entries in map literal.")
+		}
+		key, err := p.assignment()
+		if err != nil {
+			return mapExpr{}, err
+		}
+		if _, err := p.consume(COLON, "Expect ':' after map key."); err != nil {
+			return mapExpr{}, err
+		}
+		val, err := p.assignment()
+		if err != nil {
+			return mapExpr{}, err
+		}
+		out.keys = append(out.keys, key)
+		out.values = append(out.values, val)
+		if p.match(COMMA) {
+			p.advance()
+		} else {
+			break
+		}
+	}
+	if _, err := p.consume(RIGHT_BRACE, "Expect '}' after map entries."); err != nil {
+		return mapExpr{}, err
+	}
+	return out, nil
 }
 
 // arrayLiteral → "[" arrayItems "]" ;
 // arrayItems → expression ( "," expression )* ;
-func (p *Parser) arrayLiteral() (arrayExpr, error) {
+func (p *Parser) arrayLiteral(bracket token) (arrayExpr, error) {
 	array := make([]expr, 0)
 	for !p.match(RIGHT_BRACKET) {
 		if len(array) >= 255 {
@@ -805,7 +1091,7 @@ func (p *Parser) arrayLiteral() (arrayExpr, error) {
 	if err != nil {
 		return arrayExpr{}, err
 	}
-	return arrayExpr{value: array}, nil
+	return arrayExpr{bracket: bracket, value: array}, nil
 }
 
 func (p *Parser) index(callee expr) (expr, error) {
@@ -867,6 +1153,15 @@ func (p *Parser) match(expected ...tokenType) bool {
 	return slices.Contains(expected, p.peek().tokenType)
 }
 
+// matchNext peeks at the token after the current one to see if it is one of
+// the expected tokens
+func (p *Parser) matchNext(expected ...tokenType) bool {
+	if p.current+1 >= len(p.tokens) {
+		return false
+	}
+	return slices.Contains(expected, p.tokens[p.current+1].tokenType)
+}
+
 // isAtEnd returns whether there is more token to parse
 func (p *Parser) isAtEnd() bool {
 	return p.peek().tokenType == EOF
@@ -55,7 +55,21 @@ func Test_primary(t *testing.T) {
 		{
 			desc:  "array_literal",
 			input: "[5, \"this string\"]",
-			want:  arrayExpr{[]expr{literalExpr{5}, literalExpr{"this string"}}},
+			want:  arrayExpr{newTokenNoLiteralType(LEFT_BRACKET, 1, 0), []expr{literalExpr{5}, literalExpr{"this string"}}},
+		},
+		{
+			desc:  "map_literal",
+			input: "{\"a\": 1, 2: [],}",
+			want: mapExpr{
+				brace:  newTokenNoLiteralType(LEFT_BRACE, 1, 0),
+				keys:   []expr{literalExpr{"a"}, literalExpr{2}},
+				values: []expr{literalExpr{1}, arrayExpr{newTokenNoLiteralType(LEFT_BRACKET, 1, 12), []expr{}}},
+			},
+		},
+		{
+			desc:  "empty_map_literal",
+			input: "{}",
+			want:  mapExpr{brace: newTokenNoLiteralType(LEFT_BRACE, 1, 0), keys: []expr{}, values: []expr{}},
 		},
 	}
 	for _, tC := range testCases {
@@ -106,6 +120,26 @@ func Test_unary(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc:  "AWAIT",
+			input: "await task",
+			want: awaitExpr{
+				keyword: newTokenNoLiteralType(AWAIT, 1, 0),
+				value:   variableExpr{newToken(IDENTIFIER, "task", "task", 1, 6)},
+			},
+		},
+		{
+			desc:  "SPAWN",
+			input: "spawn work(1)",
+			want: spawnExpr{
+				keyword: newTokenNoLiteralType(SPAWN, 1, 0),
+				call: callExpr{
+					callee:    variableExpr{newToken(IDENTIFIER, "work", "work", 1, 6)},
+					paren:     newTokenNoLiteralType(RIGHT_PAREN, 1, 12),
+					arguments: []expr{literalExpr{1}},
+				},
+			},
+		},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
@@ -590,6 +624,22 @@ func Test_assignment(t *testing.T) {
 			want:  nil,
 			err:   NewParseError(newTokenNoLiteralType(EQUAL, 1, 2), "Invalid assignment target."),
 		},
+		{
+			desc:  "index_assignment",
+			input: "arr[0]=42",
+			want: indexSetExpr{
+				callee:  variableExpr{newToken(IDENTIFIER, "arr", "arr", 1, 0)},
+				bracket: newTokenNoLiteralType(LEFT_BRACKET, 1, 3),
+				index:   literalExpr{0},
+				value:   literalExpr{42},
+			},
+		},
+		{
+			desc:  "optional_index_assignment",
+			input: "arr?[0]=42",
+			want:  nil,
+			err:   NewParseError(newTokenNoLiteralType(EQUAL, 1, 7), "Invalid assignment target."),
+		},
 		{
 			desc:  "instance_property_assignment",
 			input: "instance.property=42",
@@ -777,6 +827,53 @@ func Test_call(t *testing.T) {
 				name: newToken(IDENTIFIER, "prop", "prop", 1, 20),
 			},
 		},
+		{
+			desc:  "optional_property_access",
+			input: "outer?.inner.prop",
+			want: optionalChainExpr{
+				chain: getExpr{
+					object: getExpr{
+						object:   variableExpr{newToken(IDENTIFIER, "outer", "outer", 1, 0)},
+						name:     newToken(IDENTIFIER, "inner", "inner", 1, 7),
+						optional: true,
+					},
+					name: newToken(IDENTIFIER, "prop", "prop", 1, 13),
+				},
+			},
+		},
+		{
+			desc:  "optional_method_call",
+			input: "obj?.say()",
+			want: optionalChainExpr{
+				chain: callExpr{
+					callee: getExpr{
+						object:   variableExpr{newToken(IDENTIFIER, "obj", "obj", 1, 0)},
+						name:     newToken(IDENTIFIER, "say", "say", 1, 5),
+						optional: true,
+					},
+					paren:     newTokenNoLiteralType(RIGHT_PAREN, 1, 9),
+					arguments: []expr{},
+				},
+			},
+		},
+		{
+			desc:  "optional_index",
+			input: "arr?[0]",
+			want: optionalChainExpr{
+				chain: indexExpr{
+					callee:   variableExpr{newToken(IDENTIFIER, "arr", "arr", 1, 0)},
+					bracket:  newTokenNoLiteralType(LEFT_BRACKET, 1, 4),
+					index:    literalExpr{0},
+					optional: true,
+				},
+			},
+		},
+		{
+			desc:  "missing_property_name_after_question_dot",
+			input: "obj?.;",
+			want:  nil,
+			err:   NewParseError(newTokenNoLiteralType(SEMICOLON, 1, 5), "Expect property name after '?.'."),
+		},
 		{
 			desc:  "missing_right_paren",
 			input: "say(\"hello\"",
@@ -841,6 +938,159 @@ func Test_expression(t *testing.T) {
 			want:  nil,
 			err:   NewParseError(newTokenNoLiteralType(SLASH, 1, 0), "Expect left operand."),
 		},
+		{
+			desc:  "arrow_function_single_param",
+			input: "x => x * 2",
+			want: functionExpr{
+				params: []token{newToken(IDENTIFIER, "x", "x", 1, 0)},
+				body: []stmt{
+					returnStmt{
+						keyword: newTokenNoLiteralType(EQUAL_GREATER, 1, 2),
+						value: binaryExpr{
+							left:     variableExpr{newToken(IDENTIFIER, "x", "x", 1, 5)},
+							operator: newTokenNoLiteralType(STAR, 1, 7),
+							right:    literalExpr{2},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:  "arrow_function_params",
+			input: "(a, b) => a",
+			want: functionExpr{
+				params: []token{
+					newToken(IDENTIFIER, "a", "a", 1, 1),
+					newToken(IDENTIFIER, "b", "b", 1, 4),
+				},
+				body: []stmt{
+					returnStmt{
+						keyword: newTokenNoLiteralType(EQUAL_GREATER, 1, 7),
+						value:   variableExpr{newToken(IDENTIFIER, "a", "a", 1, 10)},
+					},
+				},
+			},
+		},
+		{
+			desc:  "arrow_function_no_params",
+			input: "() => 1",
+			want: functionExpr{
+				params: []token{},
+				body: []stmt{
+					returnStmt{
+						keyword: newTokenNoLiteralType(EQUAL_GREATER, 1, 3),
+						value:   literalExpr{1},
+					},
+				},
+			},
+		},
+		{
+			desc:  "grouping_is_not_arrow_function",
+			input: "(a)",
+			want:  groupingExpr{variableExpr{newToken(IDENTIFIER, "a", "a", 1, 1)}},
+		},
+		{
+			desc:  "pipeline_into_call",
+			input: "data |> filter(isEven) |> len",
+			want: callExpr{
+				callee: variableExpr{newToken(IDENTIFIER, "len", "len", 1, 26)},
+				paren:  newTokenNoLiteralType(PIPE_GREATER, 1, 23),
+				arguments: []expr{
+					callExpr{
+						callee: variableExpr{newToken(IDENTIFIER, "filter", "filter", 1, 8)},
+						paren:  newTokenNoLiteralType(RIGHT_PAREN, 1, 21),
+						arguments: []expr{
+							variableExpr{newToken(IDENTIFIER, "data", "data", 1, 0)},
+							variableExpr{newToken(IDENTIFIER, "isEven", "isEven", 1, 15)},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:  "coalesce",
+			input: "a ?? b ?? 1",
+			want: logicalExpr{
+				left: logicalExpr{
+					left:     variableExpr{newToken(IDENTIFIER, "a", "a", 1, 0)},
+					operator: newTokenNoLiteralType(QUESTION_QUESTION, 1, 2),
+					right:    variableExpr{newToken(IDENTIFIER, "b", "b", 1, 5)},
+				},
+				operator: newTokenNoLiteralType(QUESTION_QUESTION, 1, 7),
+				right:    literalExpr{1},
+			},
+		},
+		{
+			desc:  "ternary_with_array_is_not_optional_index",
+			input: "ok ? [1] : [2]",
+			want: ternaryExpr{
+				condition: variableExpr{newToken(IDENTIFIER, "ok", "ok", 1, 0)},
+				thenExpr:  arrayExpr{newTokenNoLiteralType(LEFT_BRACKET, 1, 5), []expr{literalExpr{1}}},
+				elseExpr:  arrayExpr{newTokenNoLiteralType(LEFT_BRACKET, 1, 11), []expr{literalExpr{2}}},
+			},
+		},
+		{
+			desc:  "ternary_with_indexed_array_is_not_optional_index",
+			input: "c ? [1,2][0] : 3",
+			want: ternaryExpr{
+				condition: variableExpr{newToken(IDENTIFIER, "c", "c", 1, 0)},
+				thenExpr: indexExpr{
+					callee:  arrayExpr{newTokenNoLiteralType(LEFT_BRACKET, 1, 4), []expr{literalExpr{1}, literalExpr{2}}},
+					bracket: newTokenNoLiteralType(LEFT_BRACKET, 1, 9),
+					index:   literalExpr{0},
+				},
+				elseExpr: literalExpr{3},
+			},
+		},
+		{
+			desc:  "optional_index_in_ternary_branch",
+			input: "true ? arr?[0] : 3",
+			want: ternaryExpr{
+				condition: literalExpr{true},
+				thenExpr: optionalChainExpr{
+					chain: indexExpr{
+						callee:   variableExpr{newToken(IDENTIFIER, "arr", "arr", 1, 7)},
+						bracket:  newTokenNoLiteralType(LEFT_BRACKET, 1, 11),
+						index:    literalExpr{0},
+						optional: true,
+					},
+				},
+				elseExpr: literalExpr{3},
+			},
+		},
+		{
+			desc:  "optional_index_in_ternary_condition",
+			input: "arr?[0] ? 1 : 2",
+			want: ternaryExpr{
+				condition: optionalChainExpr{
+					chain: indexExpr{
+						callee:   variableExpr{newToken(IDENTIFIER, "arr", "arr", 1, 0)},
+						bracket:  newTokenNoLiteralType(LEFT_BRACKET, 1, 4),
+						index:    literalExpr{0},
+						optional: true,
+					},
+				},
+				thenExpr: literalExpr{1},
+				elseExpr: literalExpr{2},
+			},
+		},
+		{
+			desc:  "chained_optional_index",
+			input: "a?[0]?[1]",
+			want: optionalChainExpr{
+				chain: indexExpr{
+					callee: indexExpr{
+						callee:   variableExpr{newToken(IDENTIFIER, "a", "a", 1, 0)},
+						bracket:  newTokenNoLiteralType(LEFT_BRACKET, 1, 2),
+						index:    literalExpr{0},
+						optional: true,
+					},
+					bracket:  newTokenNoLiteralType(LEFT_BRACKET, 1, 6),
+					index:    literalExpr{1},
+					optional: true,
+				},
+			},
+		},
 		{
 			desc:  "or_simple",
 			input: "true or false",
@@ -1677,6 +1927,44 @@ func Test_forStatement(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc:  "for_in_loop",
+			input: "for x in xs {print x;}",
+			want: forInStmt{
+				keyword:  newTokenNoLiteralType(FOR, 1, 0),
+				name:     newToken(IDENTIFIER, "x", "x", 1, 4),
+				iterable: variableExpr{newToken(IDENTIFIER, "xs", "xs", 1, 9)},
+				body: blockStmt{
+					statements: []stmt{
+						printStmt{expr: variableExpr{newToken(IDENTIFIER, "x", "x", 1, 19)}},
+					},
+				},
+			},
+		},
+		{
+			desc:  "labeled_for_in_loop",
+			input: "outer: for x in xs {break outer;}",
+			want: forInStmt{
+				keyword:  newTokenNoLiteralType(FOR, 1, 7),
+				name:     newToken(IDENTIFIER, "x", "x", 1, 11),
+				iterable: variableExpr{newToken(IDENTIFIER, "xs", "xs", 1, 16)},
+				body: blockStmt{
+					statements: []stmt{
+						breakStmt{
+							keyword: newTokenNoLiteralType(BREAK, 1, 20),
+							label:   newToken(IDENTIFIER, "outer", "outer", 1, 26),
+						},
+					},
+				},
+				label: newToken(IDENTIFIER, "outer", "outer", 1, 0),
+			},
+		},
+		{
+			desc:  "for_in_missing_body",
+			input: "for x in xs;",
+			want:  nil,
+			err:   NewParseError(newTokenNoLiteralType(SEMICOLON, 1, 11), "Expect block."),
+		},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
@@ -1732,6 +2020,18 @@ func Test_declaration(t *testing.T) {
 			want:  nil,
 			err:   NewParseError(newTokenNoLiteralType(SEMICOLON, 1, 3), "Expect variable name."),
 		},
+		{
+			desc:  "async_function_declaration",
+			input: "async fn load() {}",
+			want: functionStmt{
+				name: newToken(IDENTIFIER, "load", "load", 1, 9),
+				literal: functionExpr{
+					params:  []token{},
+					body:    []stmt{},
+					isAsync: true,
+				},
+			},
+		},
 		{
 			desc:  "expr_statement_fallback",
 			input: "42;",
@@ -1820,6 +2120,23 @@ func Test_function(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc:  "generator_function",
+			input: "fn gen() {yield 1;}",
+			want: functionStmt{
+				name: newToken(IDENTIFIER, "gen", "gen", 1, 3),
+				literal: functionExpr{
+					params: []token{},
+					body: []stmt{
+						yieldStmt{
+							keyword: newTokenNoLiteralType(YIELD, 1, 10),
+							value:   literalExpr{1},
+						},
+					},
+					isGenerator: true,
+				},
+			},
+		},
 		{
 			desc:  "missing_function_name",
 			input: "fn() {}",
@@ -1925,6 +2242,55 @@ func Test_returnStatement(t *testing.T) {
 	}
 }
 
+func Test_yieldStatement(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		input string
+		want  stmt
+		err   error
+	}{
+		{
+			desc:  "yield_with_value",
+			input: "yield 42;",
+			want: yieldStmt{
+				keyword: newTokenNoLiteralType(YIELD, 1, 0),
+				value:   literalExpr{42},
+			},
+		},
+		{
+			desc:  "yield_without_value",
+			input: "yield;",
+			want: yieldStmt{
+				keyword: newTokenNoLiteralType(YIELD, 1, 0),
+				value:   nil,
+			},
+		},
+		{
+			desc:  "missing_semicolon",
+			input: "yield 42",
+			want:  nil,
+			err:   NewParseError(newTokenNoLiteralType(EOF, 1, 8), "Expect ';' after yield value."),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			er := NewLoxErrorReporter()
+			scanner := NewScanner(er, []byte(tC.input))
+			tokens, err := scanner.ScanTokens()
+			if err != nil {
+				t.Error(err)
+			}
+			parser := NewParser(er, tokens)
+			got, err := parser.yieldStatement()
+			if err != nil {
+				assert.Equal(t, tC.err, err)
+				return
+			}
+			assert.Equal(t, tC.want, got)
+		})
+	}
+}
+
 func Test_classDecl(t *testing.T) {
 	testCases := []struct {
 		desc  string
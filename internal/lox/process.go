@@ -0,0 +1,333 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// execOptions are the options of exec and startProcess, given as a map with
+// the keys 'stdin', 'env', 'cwd' and 'timeout'.
+type execOptions struct {
+	stdin   *string
+	env     []string
+	cwd     string
+	timeout time.Duration
+}
+
+func parseExecOptions(name string, val any) (execOptions, error) {
+	var opts execOptions
+	m, ok := val.(*loxMap)
+	if !ok {
+		return opts, builtinErrMsg(fmt.Sprintf("Options passed to '%s' must be a map.", name))
+	}
+	for _, key := range m.Keys() {
+		val, _, _ := m.Get(key)
+		switch key {
+		case "stdin":
+			s, ok := val.(string)
+			if !ok {
+				return opts, builtinErrMsg("Option 'stdin' must be a string.")
+			}
+			opts.stdin = &s
+		case "env":
+			vars, ok := val.(*loxMap)
+			if !ok {
+				return opts, builtinErrMsg("Option 'env' must be a map of strings.")
+			}
+			// The variables are added to the environment of the interpreter.
+			opts.env = os.Environ()
+			for _, k := range vars.Keys() {
+				v, _, _ := vars.Get(k)
+				kStr, ok1 := k.(string)
+				vStr, ok2 := v.(string)
+				if !ok1 || !ok2 {
+					return opts, builtinErrMsg("Option 'env' must be a map of strings.")
+				}
+				opts.env = append(opts.env, kStr+"="+vStr)
+			}
+		case "cwd":
+			s, ok := val.(string)
+			if !ok {
+				return opts, builtinErrMsg("Option 'cwd' must be a string.")
+			}
+			opts.cwd = s
+		case "timeout":
+			d, err := millis(val)
+			if err != nil {
+				return opts, builtinErrMsg("Option 'timeout' must be a number of milliseconds.")
+			}
+			opts.timeout = d
+		default:
+			return opts, builtinErrMsg(fmt.Sprintf("Unknown option '%v' passed to '%s'.", key, name))
+		}
+	}
+	return opts, nil
+}
+
+// process is a command run by exec or startProcess. Processes started by
+// startProcess have pipes to their standard streams.
+type process struct {
+	name string
+	cmd  *exec.Cmd
+	// run is the context of the run that started the process. ctx is derived
+	// from it, and is also done once the process times out.
+	run     context.Context
+	ctx     context.Context
+	cancel  context.CancelFunc
+	timeout time.Duration
+
+	// stdin is nil if the input was given as an option, or once it is closed.
+	stdinMu sync.Mutex
+	stdin   io.WriteCloser
+	stdout  *inputReader
+	stderr  *inputReader
+
+	waitOnce sync.Once
+	code     int
+	err      error
+}
+
+// newProcess builds a process from the arguments of exec and startProcess,
+// which are the name of the program, and optionally an array of arguments and
+// a map of options.
+func newProcess(i *Interpreter, name string, args []any) (*process, error) {
+	if len(args) > 3 {
+		return nil, builtinErrMsg(fmt.Sprintf("Expected at most 3 arguments but got %d.", len(args)))
+	}
+	cmdName, err := stringArg(name, "Command", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	var cmdArgs []string
+	if len(args) > 1 && args[1] != nil {
+		arr, ok := args[1].(*array)
+		if !ok {
+			return nil, builtinErrMsg(fmt.Sprintf("Arguments passed to '%s' must be an array of strings.", name))
+		}
+		for _, arg := range arr.Items() {
+			s, ok := arg.(string)
+			if !ok {
+				return nil, builtinErrMsg(fmt.Sprintf("Arguments passed to '%s' must be an array of strings.", name))
+			}
+			cmdArgs = append(cmdArgs, s)
+		}
+	}
+	var opts execOptions
+	if len(args) > 2 {
+		if opts, err = parseExecOptions(name, args[2]); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &process{name: cmdName, run: i.ctx, timeout: opts.timeout}
+	if opts.timeout > 0 {
+		p.ctx, p.cancel = context.WithTimeout(i.ctx, opts.timeout)
+	} else {
+		p.ctx, p.cancel = context.WithCancel(i.ctx)
+	}
+	p.cmd = exec.CommandContext(p.ctx, cmdName, cmdArgs...)
+	p.cmd.Env = opts.env
+	p.cmd.Dir = opts.cwd
+	if opts.stdin != nil {
+		p.cmd.Stdin = strings.NewReader(*opts.stdin)
+	}
+	return p, nil
+}
+
+// exitCode returns the exit code of the process once it has run, given the
+// error returned by Run or Wait.
+func (p *process) exitCode(err error) (int, error) {
+	defer p.cancel()
+	if p.run.Err() != nil {
+		return 0, &InterruptError{Err: context.Cause(p.run)}
+	}
+	if errors.Is(p.ctx.Err(), context.DeadlineExceeded) {
+		return 0, builtinErrMsg(fmt.Sprintf("Command '%s' timed out after %v.", p.name, p.timeout))
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return 0, builtinErrMsg(fmt.Sprintf("Can't run command '%s': %v.", p.name, err))
+	}
+	return p.cmd.ProcessState.ExitCode(), nil
+}
+
+// start starts the process with pipes to its standard streams.
+func (p *process) start() error {
+	var err error
+	if p.cmd.Stdin == nil {
+		if p.stdin, err = p.cmd.StdinPipe(); err != nil {
+			return builtinErrMsg(fmt.Sprintf("Can't run command '%s': %v.", p.name, err))
+		}
+	}
+	stdout, err := p.cmd.StdoutPipe()
+	if err != nil {
+		return builtinErrMsg(fmt.Sprintf("Can't run command '%s': %v.", p.name, err))
+	}
+	stderr, err := p.cmd.StderrPipe()
+	if err != nil {
+		return builtinErrMsg(fmt.Sprintf("Can't run command '%s': %v.", p.name, err))
+	}
+	p.stdout, p.stderr = newInputReader(stdout), newInputReader(stderr)
+	if err := p.cmd.Start(); err != nil {
+		p.cancel()
+		return builtinErrMsg(fmt.Sprintf("Can't run command '%s': %v.", p.name, err))
+	}
+	return nil
+}
+
+func (p *process) write(s string) error {
+	p.stdinMu.Lock()
+	defer p.stdinMu.Unlock()
+	if p.stdin == nil {
+		return builtinErrMsg(fmt.Sprintf("Input of command '%s' is closed.", p.name))
+	}
+	if _, err := io.WriteString(p.stdin, s); err != nil {
+		return builtinErrMsg(fmt.Sprintf("Can't write to command '%s': %v.", p.name, err))
+	}
+	return nil
+}
+
+// closeStdin closes the input of the process, which tells it that there is
+// nothing more to read.
+func (p *process) closeStdin() {
+	p.stdinMu.Lock()
+	defer p.stdinMu.Unlock()
+	if p.stdin != nil {
+		p.stdin.Close()
+		p.stdin = nil
+	}
+}
+
+// wait closes the input of the process and waits for it to exit. Output that
+// has not been read yet is kept, so that it can still be read afterwards.
+func (p *process) wait() (int, error) {
+	p.waitOnce.Do(func() {
+		p.closeStdin()
+		var wg sync.WaitGroup
+		for _, r := range []*inputReader{p.stdout, p.stderr} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.buffer()
+			}()
+		}
+		wg.Wait()
+		p.code, p.err = p.exitCode(p.cmd.Wait())
+	})
+	return p.code, p.err
+}
+
+func (p *process) iterator(i *Interpreter) iterator {
+	return p
+}
+
+// next returns the next line of the output.
+func (p *process) next() (any, bool, error) {
+	line, ok, err := p.stdout.readLine()
+	if !ok {
+		return nil, false, err
+	}
+	return line, true, nil
+}
+
+func (p *process) get(name token) (any, error) {
+	readLine := func(r *inputReader) func(i *Interpreter, args []any) (any, error) {
+		return func(i *Interpreter, args []any) (any, error) {
+			line, ok, err := r.readLine()
+			if !ok {
+				return nil, err
+			}
+			return line, nil
+		}
+	}
+	readAll := func(r *inputReader) func(i *Interpreter, args []any) (any, error) {
+		return func(i *Interpreter, args []any) (any, error) {
+			return r.readAll()
+		}
+	}
+
+	switch name.lexeme {
+	case "pid":
+		return p.cmd.Process.Pid, nil
+	case "write":
+		return newBuiltinFn("write", 1, func(i *Interpreter, args []any) (any, error) {
+			s, err := stringArg("write", "Text", args, 0)
+			if err != nil {
+				return nil, err
+			}
+			return nil, p.write(s)
+		}), nil
+	case "closeStdin":
+		return newBuiltinFn("closeStdin", 0, func(i *Interpreter, args []any) (any, error) {
+			p.closeStdin()
+			return nil, nil
+		}), nil
+	case "readLine":
+		return newBuiltinFn("readLine", 0, readLine(p.stdout)), nil
+	case "readAll":
+		return newBuiltinFn("readAll", 0, readAll(p.stdout)), nil
+	case "readErrLine":
+		return newBuiltinFn("readErrLine", 0, readLine(p.stderr)), nil
+	case "readErrAll":
+		return newBuiltinFn("readErrAll", 0, readAll(p.stderr)), nil
+	case "wait":
+		return newBuiltinFn("wait", 0, func(i *Interpreter, args []any) (any, error) {
+			return p.wait()
+		}), nil
+	case "kill":
+		return newBuiltinFn("kill", 0, func(i *Interpreter, args []any) (any, error) {
+			if err := p.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+				return nil, builtinErrMsg(fmt.Sprintf("Can't kill command '%s': %v.", p.name, err))
+			}
+			return nil, nil
+		}), nil
+	}
+	return nil, NewRuntimeError(name, fmt.Sprintf("Undefined properties '%s'", name.lexeme))
+}
+
+func (p *process) String() string {
+	return fmt.Sprintf("<process %s>", p.name)
+}
+
+func defineProcessFns(env *environment) {
+	// exec runs a command to completion, and returns a map of its output and
+	// exit code. A non-zero exit code is not an error.
+	env.define("exec", newBuiltinFn("exec", -1, func(i *Interpreter, args []any) (any, error) {
+		p, err := newProcess(i, "exec", args)
+		if err != nil {
+			return nil, err
+		}
+		var stdout, stderr bytes.Buffer
+		p.cmd.Stdout, p.cmd.Stderr = &stdout, &stderr
+		code, err := p.exitCode(p.cmd.Run())
+		if err != nil {
+			return nil, err
+		}
+		out := newMap()
+		out.Set("stdout", stdout.String())
+		out.Set("stderr", stderr.String())
+		out.Set("code", code)
+		return out, nil
+	}))
+
+	// startProcess starts a command and returns it without waiting, to stream
+	// its input and output.
+	env.define("startProcess", newBuiltinFn("startProcess", -1, func(i *Interpreter, args []any) (any, error) {
+		p, err := newProcess(i, "startProcess", args)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.start(); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}))
+}
@@ -0,0 +1,174 @@
+package lox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_process(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		code    string
+		input   string
+		want    any
+		wantErr bool
+	}{
+		{
+			desc:  "exec_stdout",
+			input: `exec("echo", ["hello", "world"])["stdout"]`,
+			want:  "hello world\n",
+		},
+		{
+			desc:  "exec_stderr_and_code",
+			code:  `var res = exec("sh", ["-c", "echo oops >&2; exit 3"]);`,
+			input: `res["stderr"] + res["code"]`,
+			want:  "oops\n3",
+		},
+		{
+			desc:  "exec_without_args",
+			input: `exec("true")["code"]`,
+			want:  0,
+		},
+		{
+			desc:  "exec_stdin",
+			input: `exec("cat", nil, {"stdin": "piped"})["stdout"]`,
+			want:  "piped",
+		},
+		{
+			desc:  "exec_env",
+			input: `exec("sh", ["-c", "printf $GOLOX_EXEC_VAR"], {"env": {"GOLOX_EXEC_VAR": "set"}})["stdout"]`,
+			want:  "set",
+		},
+		{
+			desc:  "exec_cwd",
+			input: `exec("pwd", [], {"cwd": "/"})["stdout"]`,
+			want:  "/\n",
+		},
+		{
+			desc:    "exec_timeout",
+			input:   `exec("sleep", ["5"], {"timeout": 50})`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:    "exec_unknown_command",
+			input:   `exec("golox-no-such-command")`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:    "exec_unknown_option",
+			input:   `exec("true", [], {"shell": true})`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:    "exec_args_must_be_strings",
+			input:   `exec("echo", [1])`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc: "start_process_streams",
+			code: `var p = startProcess("cat");
+			p.write("one");
+			p.write(" two");
+			p.closeStdin();
+			var line = p.readLine();`,
+			input: `line + p.wait() + (p.readLine() ?? "EOF")`,
+			want:  "one two0EOF",
+		},
+		{
+			desc: "start_process_iterates_output",
+			code: `var p = startProcess("sh", ["-c", "echo a; echo b; echo c >&2"]);
+			var lines = [];
+			for line in p {
+				append(lines, line);
+			}
+			var code = p.wait();`,
+			input: `join(lines, ",") + code + p.readErrLine()`,
+			want:  "a,b0c",
+		},
+		{
+			desc: "wait_keeps_unread_output",
+			code: `var p = startProcess("echo", ["kept"]);
+			var code = p.wait();`,
+			input: `p.readAll()`,
+			want:  "kept\n",
+		},
+		{
+			desc: "kill",
+			code: `var p = startProcess("sleep", ["5"]);
+			p.kill();`,
+			input: `p.wait()`,
+			want:  -1,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := runScript(t, tC.code, tC.input)
+			assert.Equal(t, tC.want, got)
+			if tC.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_process_disabled(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		opts       []RuntimeOption
+		code       string
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			desc:       "exec",
+			opts:       []RuntimeOption{WithExec(false)},
+			code:       `exec("true");`,
+			wantStderr: "[line 1] Runtime Error at ')': Permission denied: 'exec' is disabled.\n",
+		},
+		{
+			desc:       "start_process",
+			opts:       []RuntimeOption{WithExec(false)},
+			code:       `print startProcess; startProcess("true");`,
+			wantStdout: "<denied startProcess>\n",
+			wantStderr: "[line 1] Runtime Error at ')': Permission denied: 'startProcess' is disabled.\n",
+		},
+		{
+			desc:       "keeps_other_capabilities",
+			opts:       []RuntimeOption{WithExec(false)},
+			code:       `print clock() > 0;`,
+			wantStdout: "true\n",
+		},
+		{
+			desc:       "enabled_with_capabilities",
+			opts:       []RuntimeOption{WithCapabilities(CapClock), WithExec(true)},
+			code:       `print exec("echo", ["hi"])["stdout"]; print fs;`,
+			wantStdout: "hi\n\n<denied fs>\n",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			opts := append([]RuntimeOption{WithStdout(&stdout), WithStderr(&stderr)}, tC.opts...)
+			er := NewLoxErrorReporter()
+			interpreter := NewInterpreter(er)
+			resolver := NewResolver(er, interpreter)
+			rt := NewRuntime(er, interpreter, resolver, opts...)
+
+			rt.run([]byte(tC.code))
+			assert.Equal(t, tC.wantStdout, stdout.String())
+			assert.Equal(t, tC.wantStderr, withoutSnippet(stderr.String()))
+		})
+	}
+}
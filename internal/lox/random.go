@@ -0,0 +1,118 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+)
+
+// randomSource generates the numbers of the random module. It is shared by
+// forked interpreters, so that a seed fixes the numbers of a whole run.
+type randomSource struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// newRandomSource returns a source with a random seed.
+func newRandomSource() *randomSource {
+	return &randomSource{r: rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))}
+}
+
+func newSeededSource(seed int64) *randomSource {
+	s := &randomSource{}
+	s.seed(seed)
+	return s
+}
+
+func (s *randomSource) seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.r = rand.New(rand.NewPCG(uint64(seed), 0))
+}
+
+// intN returns a number in [0, n).
+func (s *randomSource) intN(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.IntN(n)
+}
+
+// between returns a number in [lo, hi]. The span is computed as an unsigned
+// number, so that ranges as wide as all ints don't overflow.
+func (s *randomSource) between(lo, hi int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	span := uint64(hi) - uint64(lo)
+	if span == math.MaxUint64 {
+		return int(s.r.Uint64())
+	}
+	return lo + int(s.r.Uint64N(span+1))
+}
+
+func (s *randomSource) float() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Float64()
+}
+
+func (s *randomSource) shuffle(n int, swap func(i, j int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.r.Shuffle(n, swap)
+}
+
+func defineRandomModule(env *environment) {
+	m := newModule("random")
+
+	// int returns an integer between lo and hi, both included.
+	m.define("int", 2, func(i *Interpreter, args []any) (any, error) {
+		lo, ok1 := args[0].(int)
+		hi, ok2 := args[1].(int)
+		if !ok1 || !ok2 {
+			return nil, builtinErrMsg("Bounds passed to 'random.int' must be integers.")
+		}
+		if hi < lo {
+			return nil, builtinErrMsg(fmt.Sprintf("Can't pick a number between %d and %d.", lo, hi))
+		}
+		return i.random.between(lo, hi), nil
+	})
+
+	// float returns a number in [0, 1).
+	m.define("float", 0, func(i *Interpreter, args []any) (any, error) {
+		return i.random.float(), nil
+	})
+
+	m.define("choice", 1, func(i *Interpreter, args []any) (any, error) {
+		arr, err := arrayArg("random.choice", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		items := arr.Items()
+		if len(items) == 0 {
+			return nil, builtinErrMsg("Can't choose from an empty array.")
+		}
+		return items[i.random.intN(len(items))], nil
+	})
+
+	// shuffle shuffles the array in place.
+	m.define("shuffle", 1, func(i *Interpreter, args []any) (any, error) {
+		arr, err := arrayArg("random.shuffle", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		arr.Shuffle(i.random.shuffle)
+		return nil, nil
+	})
+
+	m.define("seed", 1, func(i *Interpreter, args []any) (any, error) {
+		seed, ok := args[0].(int)
+		if !ok {
+			return nil, builtinErrMsg("Seed passed to 'random.seed' must be an integer.")
+		}
+		i.random.seed(int64(seed))
+		return nil, nil
+	})
+
+	env.define("random", m)
+}
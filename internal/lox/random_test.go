@@ -0,0 +1,148 @@
+package lox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_random(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		code    string
+		input   string
+		want    any
+		wantErr bool
+	}{
+		{
+			desc: "int_in_bounds",
+			code: `var ok = true;
+			for var n = 0; n < 200; n = n + 1 {
+				var x = random.int(-2, 2);
+				ok = ok and x >= -2 and x <= 2;
+			}`,
+			input: `ok`,
+			want:  true,
+		},
+		{
+			desc:  "int_single_value",
+			input: `random.int(3, 3)`,
+			want:  3,
+		},
+		{
+			desc:  "int_max_bound",
+			input: `random.int(9223372036854775807, 9223372036854775807)`,
+			want:  9223372036854775807,
+		},
+		{
+			desc: "int_wide_range",
+			code: `var ok = true;
+			for var n = 0; n < 200; n = n + 1 {
+				ok = ok and random.int(0, 9223372036854775807) >= 0;
+				ok = ok and random.int(-9223372036854775807 - 1, -1) < 0;
+			}`,
+			input: `ok`,
+			want:  true,
+		},
+		{
+			desc:  "int_full_range",
+			code:  `var x = random.int(-9223372036854775807 - 1, 9223372036854775807);`,
+			input: `x == x`,
+			want:  true,
+		},
+		{
+			desc:    "int_empty_range",
+			input:   `random.int(3, 2)`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:    "int_bounds_must_be_integers",
+			input:   `random.int(0, 1.5)`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:  "float_in_range",
+			code:  `var f = random.float();`,
+			input: `f >= 0 and f < 1`,
+			want:  true,
+		},
+		{
+			desc:  "choice",
+			input: `contains(["a", "b", "c"], random.choice(["a", "b", "c"]))`,
+			want:  true,
+		},
+		{
+			desc:    "choice_empty",
+			input:   `random.choice([])`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc: "shuffle_keeps_items",
+			code: `var arr = [1, 2, 3, 4, 5];
+			random.shuffle(arr);`,
+			input: `join(sort(arr), ",")`,
+			want:  "1,2,3,4,5",
+		},
+		{
+			desc: "seed_repeats_sequence",
+			code: `fn draw() {
+				var arr = [1, 2, 3, 4, 5, 6, 7, 8];
+				random.shuffle(arr);
+				return join(arr, ",") + random.int(0, 1000000) + random.float();
+			}
+			random.seed(42);
+			var first = draw();
+			random.seed(42);`,
+			input: `draw() == first`,
+			want:  true,
+		},
+		{
+			desc:    "seed_must_be_integer",
+			input:   `random.seed("x")`,
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := runScript(t, tC.code, tC.input)
+			assert.Equal(t, tC.want, got)
+			if tC.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_random_withSeed(t *testing.T) {
+	code := `var draws = [];
+	fn draw() {
+		append(draws, random.int(1, 1000000));
+	}
+	var task = spawn draw();
+	task.join();
+	draw();
+	setTimeout(draw, 1);`
+
+	run := func() []any {
+		er := NewLoxErrorReporter()
+		interpreter := NewInterpreter(er)
+		resolver := NewResolver(er, interpreter)
+		rt := NewRuntime(er, interpreter, resolver, WithSeed(7), WithClock(&fakeClock{}))
+		rt.run([]byte(code))
+		assert.False(t, er.HadRuntimeError())
+		return interpreter.globals.values["draws"].(*array).Items()
+	}
+
+	first := run()
+	assert.Len(t, first, 3)
+	assert.Equal(t, first, run())
+}
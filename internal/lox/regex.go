@@ -0,0 +1,209 @@
+package lox
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// regexPattern is a compiled regular expression, returned by regex.compile.
+type regexPattern struct {
+	re *regexp.Regexp
+}
+
+func compilePattern(name string, val any) (*regexPattern, error) {
+	switch v := val.(type) {
+	case *regexPattern:
+		return v, nil
+	case string:
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, builtinErrMsg(fmt.Sprintf("Invalid regular expression '%s': %v.", v, err))
+		}
+		return &regexPattern{re: re}, nil
+	default:
+		return nil, builtinErrMsg(fmt.Sprintf("Pattern passed to '%s' must be a string or a compiled pattern.", name))
+	}
+}
+
+// groups returns the text matched by each group of a submatch, with nil for
+// groups that did not take part in the match.
+func groups(s string, loc []int) *array {
+	out := newArray()
+	for idx := 0; idx < len(loc); idx += 2 {
+		if loc[idx] < 0 {
+			out.Append(nil)
+			continue
+		}
+		out.Append(s[loc[idx]:loc[idx+1]])
+	}
+	return out
+}
+
+// regexOp is an operation available both as a method of compiled patterns and
+// as a function of the regex module, where the pattern is the first argument.
+type regexOp struct {
+	arity int
+	fn    func(i *Interpreter, p *regexPattern, args []any) (any, error)
+}
+
+var regexOps = map[string]regexOp{
+	"match": {1, func(i *Interpreter, p *regexPattern, args []any) (any, error) {
+		s, err := stringArg("match", "Text", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return p.re.MatchString(s), nil
+	}},
+
+	// find returns the first match, or nil if there is none.
+	"find": {1, func(i *Interpreter, p *regexPattern, args []any) (any, error) {
+		s, err := stringArg("find", "Text", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		loc := p.re.FindStringIndex(s)
+		if loc == nil {
+			return nil, nil
+		}
+		return s[loc[0]:loc[1]], nil
+	}},
+
+	"findAll": {1, func(i *Interpreter, p *regexPattern, args []any) (any, error) {
+		s, err := stringArg("findAll", "Text", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		out := newArray()
+		for _, match := range p.re.FindAllString(s, -1) {
+			out.Append(match)
+		}
+		return out, nil
+	}},
+
+	// groups returns an array of the first match followed by its capture groups,
+	// or nil if there is no match.
+	"groups": {1, func(i *Interpreter, p *regexPattern, args []any) (any, error) {
+		s, err := stringArg("groups", "Text", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		loc := p.re.FindStringSubmatchIndex(s)
+		if loc == nil {
+			return nil, nil
+		}
+		return groups(s, loc), nil
+	}},
+
+	// namedGroups returns a map from the names of the named capture groups to
+	// the text they matched, or nil if there is no match.
+	"namedGroups": {1, func(i *Interpreter, p *regexPattern, args []any) (any, error) {
+		s, err := stringArg("namedGroups", "Text", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		loc := p.re.FindStringSubmatchIndex(s)
+		if loc == nil {
+			return nil, nil
+		}
+		matched := groups(s, loc)
+		out := newMap()
+		for idx, name := range p.re.SubexpNames() {
+			if name != "" {
+				out.Set(name, matched.Get(idx))
+			}
+		}
+		return out, nil
+	}},
+
+	// replace replaces every match with a string, in which $1 or ${name} stand
+	// for the text of a group, or with the result of calling a function with the
+	// matched text.
+	"replace": {2, func(i *Interpreter, p *regexPattern, args []any) (any, error) {
+		s, err := stringArg("replace", "Text", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if repl, ok := args[1].(string); ok {
+			return p.re.ReplaceAllString(s, repl), nil
+		}
+		fn, err := callbackArg("replace", args, 1, 1)
+		if err != nil {
+			return nil, builtinErrMsg("Replacement passed to 'replace' must be a string or a function that takes one argument.")
+		}
+		var callErr error
+		out := p.re.ReplaceAllStringFunc(s, func(match string) string {
+			if callErr != nil {
+				return match
+			}
+			res, err := i.call(i.site, fn, []any{match})
+			if err != nil {
+				callErr = err
+				return match
+			}
+			str, ok := res.(string)
+			if !ok {
+				callErr = builtinErrMsg("Replacement function must return a string.")
+				return match
+			}
+			return str
+		})
+		if callErr != nil {
+			return nil, callErr
+		}
+		return out, nil
+	}},
+
+	"split": {1, func(i *Interpreter, p *regexPattern, args []any) (any, error) {
+		s, err := stringArg("split", "Text", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		out := newArray()
+		for _, part := range p.re.Split(s, -1) {
+			out.Append(part)
+		}
+		return out, nil
+	}},
+}
+
+func (p *regexPattern) get(name token) (any, error) {
+	if name.lexeme == "source" {
+		return p.re.String(), nil
+	}
+	op, ok := regexOps[name.lexeme]
+	if !ok {
+		return nil, NewRuntimeError(name, fmt.Sprintf("Undefined properties '%s'", name.lexeme))
+	}
+	return newBuiltinFn(name.lexeme, op.arity, func(i *Interpreter, args []any) (any, error) {
+		return op.fn(i, p, args)
+	}), nil
+}
+
+func (p *regexPattern) String() string {
+	return fmt.Sprintf("<regex %s>", p.re.String())
+}
+
+func defineRegexModule(env *environment) {
+	m := newModule("regex")
+
+	m.define("compile", 1, func(i *Interpreter, args []any) (any, error) {
+		if _, ok := args[0].(string); !ok {
+			return nil, builtinErrMsg("Pattern passed to 'regex.compile' must be a string.")
+		}
+		return compilePattern("regex.compile", args[0])
+	})
+
+	// The module functions take a pattern, either as a string or compiled,
+	// followed by the arguments of the pattern's method of the same name.
+	for name, op := range regexOps {
+		m.define(name, op.arity+1, func(i *Interpreter, args []any) (any, error) {
+			p, err := compilePattern("regex."+name, args[0])
+			if err != nil {
+				return nil, err
+			}
+			return op.fn(i, p, args[1:])
+		})
+	}
+
+	env.define("regex", m)
+}
@@ -0,0 +1,117 @@
+package lox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_regex(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		code    string
+		input   string
+		want    any
+		wantErr bool
+	}{
+		{
+			desc:  "compiled_match",
+			code:  `var re = regex.compile("^[a-z]+$");`,
+			input: `re.match("abc") and !re.match("ab1")`,
+			want:  true,
+		},
+		{
+			desc:  "module_function_with_string_pattern",
+			input: `regex.find("[0-9]+", "abc 123 456")`,
+			want:  "123",
+		},
+		{
+			desc:  "find_no_match",
+			input: `regex.find("[0-9]+", "abc")`,
+			want:  nil,
+		},
+		{
+			desc:  "find_all",
+			code:  `var re = regex.compile("[0-9]+");`,
+			input: `join(re.findAll("a1b22c333"), ",")`,
+			want:  "1,22,333",
+		},
+		{
+			desc:  "groups",
+			code:  `var re = regex.compile("([a-z]+)(-([0-9]+))?");`,
+			input: `join(re.groups("abc"), ",")`,
+			want:  "abc,abc,<nil>,<nil>",
+		},
+		{
+			desc:  "named_groups",
+			code:  `var re = regex.compile("(?P<key>[a-z]+)=(?P<value>[0-9]+)");`,
+			input: `re.namedGroups("x: a=1")["key"] + re.namedGroups("x: a=1")["value"]`,
+			want:  "a1",
+		},
+		{
+			desc:  "replace_with_template",
+			code:  `var re = regex.compile("([a-z]+)@([a-z]+)");`,
+			input: `re.replace("me@host you@there", "$2:$1")`,
+			want:  "host:me there:you",
+		},
+		{
+			desc: "replace_with_callback",
+			code: `var count = 0;
+			fn number(m) {
+				count = count + 1;
+				return m + count;
+			}`,
+			input: `regex.replace("o", "foo", number)`,
+			want:  "fo1o2",
+		},
+		{
+			desc:    "replace_callback_error",
+			input:   `regex.replace("o", "foo", m => m - 1)`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:    "replace_callback_must_return_string",
+			input:   `regex.replace("o", "foo", m => 1)`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:  "split",
+			input: `join(regex.split(" *, *", "a , b,c"), "|")`,
+			want:  "a|b|c",
+		},
+		{
+			desc:  "pattern_source",
+			code:  `var re = regex.compile("a+");`,
+			input: `re.source`,
+			want:  "a+",
+		},
+		{
+			desc:    "invalid_pattern",
+			input:   `regex.compile("(")`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			desc:    "non_string_text",
+			input:   `regex.match("a", 1)`,
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := runScript(t, tC.code, tC.input)
+			assert.Equal(t, tC.want, got)
+			if tC.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -40,6 +40,13 @@ func (r *Resolver) resolveStmt(s stmt) error {
 }
 
 func (r *Resolver) resolveFunction(e functionExpr, ft fnType) error {
+	switch {
+	case ft == fnTypeINITIALIZER:
+	case e.isAsync:
+		ft = fnTypeASYNC
+	case e.isGenerator:
+		ft = fnTypeGENERATOR
+	}
 	enclosingFn := r.currentFn
 	r.currentFn = ft
 	defer func(r *Resolver) {
@@ -97,6 +104,13 @@ func (r *Resolver) endLoop() {
 	r.loopStack.pop()
 }
 
+func (r *Resolver) visitAwaitExpr(e awaitExpr) (any, error) {
+	if r.currentFn != fnTypeASYNC {
+		r.er.ParseError(e.keyword, "Can't use 'await' outside of an async function.")
+	}
+	return r.resolveExpr(e.value)
+}
+
 func (r *Resolver) visitBinaryExpr(e binaryExpr) (any, error) {
 	r.resolveExpr(e.left)
 	r.resolveExpr(e.right)
@@ -158,12 +172,20 @@ func (r *Resolver) visitGetExpr(e getExpr) (any, error) {
 	return nil, nil
 }
 
+func (r *Resolver) visitOptionalChainExpr(e optionalChainExpr) (any, error) {
+	return r.resolveExpr(e.chain)
+}
+
 func (r *Resolver) visitSetExpr(e setExpr) (any, error) {
 	r.resolveExpr(e.value)
 	r.resolveExpr(e.object)
 	return nil, nil
 }
 
+func (r *Resolver) visitSpawnExpr(e spawnExpr) (any, error) {
+	return r.resolveExpr(e.call)
+}
+
 func (r *Resolver) visitThisExpr(e thisExpr) (any, error) {
 	if r.currentClass == classTypeNONE {
 		r.er.ParseError(e.keyword, "Can't use 'this' outside of a class.")
@@ -199,6 +221,21 @@ func (r *Resolver) visitIndexExpr(e indexExpr) (any, error) {
 	return nil, nil
 }
 
+func (r *Resolver) visitIndexSetExpr(e indexSetExpr) (any, error) {
+	r.resolveExpr(e.value)
+	r.resolveExpr(e.callee)
+	r.resolveExpr(e.index)
+	return nil, nil
+}
+
+func (r *Resolver) visitMapExpr(e mapExpr) (any, error) {
+	for idx, key := range e.keys {
+		r.resolveExpr(key)
+		r.resolveExpr(e.values[idx])
+	}
+	return nil, nil
+}
+
 func (r *Resolver) visitExprStmt(s exprStmt) error {
 	r.resolveExpr(s.expr)
 	return nil
@@ -232,6 +269,9 @@ func (r *Resolver) visitReturnStmt(s returnStmt) error {
 		if r.currentFn == fnTypeINITIALIZER {
 			r.er.ParseError(s.keyword, "Can't return value from an initializer.")
 		}
+		if r.currentFn == fnTypeGENERATOR {
+			r.er.ParseError(s.keyword, "Can't return value from a generator.")
+		}
 		r.resolveExpr(s.value)
 	}
 	return nil
@@ -270,6 +310,21 @@ func (r *Resolver) visitForStmt(s forStmt) error {
 	return nil
 }
 
+func (r *Resolver) visitForInStmt(s forInStmt) error {
+	if s.label.lexeme != "" && r.loopStack.contains(s.label.lexeme) {
+		r.er.ParseError(s.label, "Label already belongs to outer loops.")
+	}
+	r.resolveExpr(s.iterable)
+	r.beginLoop(s.label.lexeme)
+	defer r.endLoop()
+	r.beginScope()
+	defer r.endScope()
+	r.declare(s.name)
+	r.define(s.name)
+	r.resolveStmt(s.body)
+	return nil
+}
+
 func (r *Resolver) visitBreakStmt(s breakStmt) error {
 	if r.loopStack.isEmpty() {
 		r.er.ParseError(s.keyword, "Break statement must be in a loop.")
@@ -290,6 +345,21 @@ func (r *Resolver) visitContinueStmt(s continueStmt) error {
 	return nil
 }
 
+func (r *Resolver) visitYieldStmt(s yieldStmt) error {
+	switch r.currentFn {
+	case fnTypeNONE:
+		r.er.ParseError(s.keyword, "Can't yield from top-level code.")
+	case fnTypeINITIALIZER:
+		r.er.ParseError(s.keyword, "Can't yield from an initializer.")
+	case fnTypeASYNC:
+		r.er.ParseError(s.keyword, "Can't yield from an async function.")
+	}
+	if s.value != nil {
+		r.resolveExpr(s.value)
+	}
+	return nil
+}
+
 func (r *Resolver) visitBlockStmt(s blockStmt) error {
 	r.beginScope()
 	defer r.endScope()
@@ -324,6 +394,9 @@ func (r *Resolver) visitClassStmt(s classStmt) error {
 		methodType := fnTypeMETHOD
 		if method.name.lexeme == "init" {
 			methodType = fnTypeINITIALIZER
+			if method.literal.isAsync {
+				r.er.ParseError(method.name, "Can't make an initializer async.")
+			}
 		}
 		r.resolveFunction(method.literal, methodType)
 	}
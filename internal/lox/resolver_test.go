@@ -30,6 +30,15 @@ func TestResolve(t *testing.T) {
 				}: 1,
 			},
 		},
+		{
+			name:  "assignment of an array literal",
+			input: `{var x; x = [1];}`,
+			expectedLocals: map[expr]int{
+				assignExpr{
+					name: newToken(IDENTIFIER, "x", "x", 1, 8),
+				}: 0,
+			},
+		},
 	}
 
 	for _, tt := range tests {
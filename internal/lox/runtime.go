@@ -1,7 +1,7 @@
 package lox
 
 import (
-	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -10,46 +10,238 @@ import (
 )
 
 type Runtime struct {
-	er ErrorReporter
-	i  *Interpreter
-	r  *Resolver
+	er    ErrorReporter
+	i     *Interpreter
+	r     *Resolver
+	loop  *eventLoop
+	fs    FS
+	args  []string
+	stdin *inputReader
+	// stdout is written to by scripts, and stderr receives the errors printed
+	// by the error reporter. It is os.Stderr if nil, and VMs only print the
+	// errors they return if it is set.
+	stdout io.Writer
+	stderr io.Writer
+	// random is nil unless a seed is given, in which case it replaces the
+	// randomly seeded source of the interpreter.
+	random *randomSource
+	// caps are the capabilities of scripts, or nil if they have all of them.
+	caps map[Capability]bool
+	// ctx and steps limit how long scripts may run.
+	ctx   context.Context
+	steps *budget
+	mem   *budget
+	// maxDepth is the maximum call depth of scripts, if it is positive.
+	maxDepth int
+	// exit is set once the script calls the exit native function.
+	exit *scriptExit
+	// interrupt is set once the script is stopped by ctx or steps.
+	interrupt *InterruptError
 }
 
-func NewRuntime(er ErrorReporter, i *Interpreter, r *Resolver) *Runtime {
-	return &Runtime{er: er, i: i, r: r}
+type RuntimeOption func(*Runtime)
+
+// WithClock sets the clock used by the event loop to schedule timers, and by
+// clock, nanotime and the datetime module to tell the time.
+func WithClock(c Clock) RuntimeOption {
+	return func(rt *Runtime) {
+		rt.loop = newEventLoop(c)
+	}
+}
+
+// WithFS sets the filesystem used by the fs module, which is the filesystem of
+// the OS by default.
+func WithFS(fsys FS) RuntimeOption {
+	return func(rt *Runtime) {
+		rt.fs = fsys
+	}
+}
+
+// WithStdin sets the reader that scripts read their input from, which is
+// os.Stdin by default.
+func WithStdin(r io.Reader) RuntimeOption {
+	return func(rt *Runtime) {
+		rt.stdin = newInputReader(r)
+	}
+}
+
+// WithStdout sets the writer that scripts print to, which is os.Stdout by
+// default.
+func WithStdout(w io.Writer) RuntimeOption {
+	return func(rt *Runtime) {
+		rt.stdout = w
+	}
+}
+
+// WithStderr sets the writer that errors are printed to, which is os.Stderr by
+// default. It is used by error reporters that have a SetOutput method, such as
+// LoxErrorReporter. A VM, which returns errors instead, also prints them to w.
+func WithStderr(w io.Writer) RuntimeOption {
+	return func(rt *Runtime) {
+		rt.stderr = w
+	}
+}
+
+// WithArgs sets the command-line arguments exposed to scripts as 'args'.
+func WithArgs(args []string) RuntimeOption {
+	return func(rt *Runtime) {
+		rt.args = args
+	}
+}
+
+// WithSeed seeds the random module, so that runs of a script are reproducible.
+func WithSeed(seed int64) RuntimeOption {
+	return func(rt *Runtime) {
+		rt.random = newSeededSource(seed)
+	}
+}
+
+// WithExec sets whether scripts may run commands with exec and startProcess,
+// which they may by default. It adds or removes CapExec from the capabilities
+// of scripts, so when disabled both raise a permission error.
+func WithExec(allow bool) RuntimeOption {
+	return func(rt *Runtime) {
+		if rt.caps == nil {
+			if allow {
+				return
+			}
+			rt.caps = make(map[Capability]bool, len(capabilityGlobals))
+			for c := range capabilityGlobals {
+				rt.caps[c] = true
+			}
+		}
+		rt.caps[CapExec] = allow
+	}
+}
+
+// WithCapabilities gives scripts only the listed capabilities, instead of all
+// of them. The natives of the others raise a permission error when used.
+func WithCapabilities(caps ...Capability) RuntimeOption {
+	return func(rt *Runtime) {
+		rt.caps = make(map[Capability]bool, len(caps))
+		for _, c := range caps {
+			rt.caps[c] = true
+		}
+	}
+}
+
+// WithContext sets the context of scripts, which are stopped with an
+// InterruptError once it is done.
+func WithContext(ctx context.Context) RuntimeOption {
+	return func(rt *Runtime) {
+		rt.ctx = ctx
+	}
+}
+
+// WithStepLimit sets the number of steps each run of a script may take, where
+// a step is a loop iteration or a function call, including those of spawned
+// tasks and timers. Scripts that take more are stopped with an InterruptError
+// wrapping ErrStepLimit.
+func WithStepLimit(steps int64) RuntimeOption {
+	return func(rt *Runtime) {
+		rt.steps = newBudget(steps)
+	}
+}
+
+// WithMemoryLimit sets the approximate number of bytes that each run of a
+// script may allocate for strings, arrays, maps and instances, whether or not
+// they are still in use. Allocations past the limit raise a "Memory limit
+// exceeded." runtime error.
+func WithMemoryLimit(bytes int64) RuntimeOption {
+	return func(rt *Runtime) {
+		rt.mem = newBudget(bytes)
+	}
+}
+
+// WithMaxDepth sets the maximum depth of nested calls, which is 10000 by
+// default. Deeper calls raise a "Stack overflow." runtime error at the call
+// site.
+func WithMaxDepth(depth int) RuntimeOption {
+	return func(rt *Runtime) {
+		rt.maxDepth = depth
+	}
+}
+
+func NewRuntime(er ErrorReporter, i *Interpreter, r *Resolver, opts ...RuntimeOption) *Runtime {
+	rt := &Runtime{er: er, i: i, r: r, loop: newEventLoop(systemClock{}), fs: osFS{}}
+	rt.stdin = newInputReader(os.Stdin)
+	rt.stdout = os.Stdout
+	rt.ctx = context.Background()
+	for _, opt := range opts {
+		opt(rt)
+	}
+	i.loop = rt.loop
+	i.fs = rt.fs
+	i.stdin = rt.stdin
+	i.stdout = newSyncWriter(rt.stdout)
+	if o, ok := er.(interface{ SetOutput(io.Writer) }); ok && rt.stderr != nil {
+		o.SetOutput(rt.stderr)
+	}
+	if rt.caps != nil {
+		i.caps = rt.caps
+		i.denyCapabilities()
+	}
+	i.steps = rt.steps
+	i.mem = rt.mem
+	if rt.maxDepth > 0 {
+		i.maxDepth = rt.maxDepth
+	}
+	if rt.random != nil {
+		i.random = rt.random
+	}
+	args := newArray()
+	for _, arg := range rt.args {
+		args.Append(arg)
+	}
+	i.globals.define("args", args)
+	return rt
+}
+
+// errOut returns the writer that errors are printed to.
+func (rt *Runtime) errOut() io.Writer {
+	if rt.stderr == nil {
+		return os.Stderr
+	}
+	return rt.stderr
 }
 
 func (rt *Runtime) RunFile(filename string) {
 	f, err := os.Open(filename)
 	if err != nil {
-		fmt.Printf("can't open file '%s': %v\n", filename, err)
+		fmt.Fprintf(rt.errOut(), "can't open file '%s': %v\n", filename, err)
 		os.Exit(2)
 	}
 
 	b, err := io.ReadAll(f)
 	if err != nil {
-		fmt.Printf("can't read file '%s': %v\n", filename, err)
+		fmt.Fprintf(rt.errOut(), "can't read file '%s': %v\n", filename, err)
 		os.Exit(2)
 	}
 
+	rt.i.file = filename
 	rt.run(b)
+	if rt.exit != nil {
+		os.Exit(rt.exit.code)
+	}
 	if rt.er.HadError() {
 		os.Exit(65)
 	}
-	if rt.er.HadRuntimeError() {
+	if rt.er.HadRuntimeError() || rt.interrupt != nil {
 		os.Exit(70)
 	}
 }
 
 func (rt *Runtime) RunPrompt() {
-	var stdout io.Writer = os.Stdout
+	stdout := rt.i.stdout
 	fmt.Fprint(stdout, "Golox 0.02\n")
 	for {
 		fmt.Fprint(stdout, ">> ")
 
-		// Wait for user input
-		stdin := bufio.NewReader(os.Stdin)
-		input, err := stdin.ReadBytes('\n')
+		// Wait for user input. The reader is shared with scripts, so that input
+		// they don't read is not lost.
+		rt.stdin.mu.Lock()
+		input, err := rt.stdin.r.ReadBytes('\n')
+		rt.stdin.mu.Unlock()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				os.Exit(0)
@@ -61,6 +253,9 @@ func (rt *Runtime) RunPrompt() {
 
 		// Remove delim \n from input before running
 		rt.run(input[:len(input)-1])
+		if rt.exit != nil {
+			os.Exit(rt.exit.code)
+		}
 		// If there is any error just continue, error will be reported somewhere else
 		rt.er.ResetError()
 	}
@@ -69,6 +264,9 @@ func (rt *Runtime) RunPrompt() {
 func (rt *Runtime) run(source []byte) {
 	// Static errors are printed but operation is continued,
 	// so only fatal errors are returned.
+	if s, ok := rt.er.(interface{ setSource(string, []byte) }); ok {
+		s.setSource(rt.i.file, source)
+	}
 	scanner := NewScanner(rt.er, source)
 	tokens, err := scanner.ScanTokens()
 	if err != nil {
@@ -93,11 +291,55 @@ func (rt *Runtime) run(source []byte) {
 		return
 	}
 
-	err = rt.i.Interpret(stmts)
+	err = rt.i.InterpretContext(rt.ctx, stmts)
+	if errors.As(err, &rt.exit) || rt.interrupted(err) {
+		return
+	}
 	if err != nil {
 		log.Fatal("Interpreter Error: ", err.Error())
 	}
+	err = rt.runEventLoop()
+	if errors.As(err, &rt.exit) || rt.interrupted(err) {
+		return
+	}
+	if err != nil {
+		log.Fatal("Event Loop Error: ", err.Error())
+	}
 	if rt.er.HadRuntimeError() {
 		return
 	}
 }
+
+// interrupted reports whether err is an InterruptError, in which case it is
+// printed and the timers that are left are dropped.
+func (rt *Runtime) interrupted(err error) bool {
+	if !errors.As(err, &rt.interrupt) {
+		return false
+	}
+	fmt.Fprintln(rt.errOut(), rt.interrupt.Error())
+	rt.loop.clear()
+	return true
+}
+
+// runEventLoop runs timers and async callbacks until there are none left.
+// Runtime errors are reported the same way as in Interpret.
+func (rt *Runtime) runEventLoop() error {
+	for {
+		fn, ok := rt.loop.next(rt.i.ctx.Done())
+		if err := rt.i.interrupted(); err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		err := fn()
+		if err != nil {
+			var rtErr RuntimeError
+			if errors.As(err, &rtErr) {
+				rt.er.RuntimeError(rtErr)
+			} else {
+				return err
+			}
+		}
+	}
+}
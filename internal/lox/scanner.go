@@ -69,14 +69,20 @@ func (s *Scanner) scanToken() error {
 		s.addToken(MINUS, "-")
 	case '+':
 		s.addToken(PLUS, "+")
-	case '?':
-		s.addToken(QUESTION, "?")
 	case ';':
 		s.addToken(SEMICOLON, ";")
 	case '*':
 		s.addToken(STAR, "*")
 
 	// One or two character tokens.
+	case '?':
+		if s.matchConsume('.') {
+			s.addToken(QUESTION_DOT, "?.")
+		} else if s.matchConsume('?') {
+			s.addToken(QUESTION_QUESTION, "??")
+		} else {
+			s.addToken(QUESTION, "?")
+		}
 	case '!':
 		if s.matchConsume('=') {
 			s.addToken(BANG_EQUAL, "!=")
@@ -86,6 +92,8 @@ func (s *Scanner) scanToken() error {
 	case '=':
 		if s.matchConsume('=') {
 			s.addToken(EQUAL_EQUAL, "==")
+		} else if s.matchConsume('>') {
+			s.addToken(EQUAL_GREATER, "=>")
 		} else {
 			s.addToken(EQUAL, "=")
 		}
@@ -101,6 +109,12 @@ func (s *Scanner) scanToken() error {
 		} else {
 			s.addToken(LESS, "<")
 		}
+	case '|':
+		if s.matchConsume('>') {
+			s.addToken(PIPE_GREATER, "|>")
+		} else {
+			s.er.ScanError(s.errorToken(), "unsupported character '|'")
+		}
 
 	case '/':
 		if s.matchConsume('/') {
@@ -133,7 +147,7 @@ func (s *Scanner) scanToken() error {
 		case isAlpha(char):
 			return s.addTokenIdentifier()
 		default:
-			s.er.ScanError(s.line, fmt.Sprintf("unsupported character '%s'", string(char)))
+			s.er.ScanError(s.errorToken(), fmt.Sprintf("unsupported character '%s'", string(char)))
 			return nil
 		}
 	}
@@ -148,6 +162,12 @@ func (s Scanner) makeLexeme() string {
 	return string(s.source[s.start:s.current])
 }
 
+// errorToken returns a token for the lexeme being scanned, to report an error
+// at.
+func (s Scanner) errorToken() token {
+	return newToken("", s.makeLexeme(), nil, s.line, s.start)
+}
+
 // advance **consumes** a character and returns it
 func (s *Scanner) advance() byte {
 	s.current++
@@ -181,10 +201,14 @@ func (s *Scanner) matchConsume(expected byte) bool {
 }
 
 func (s *Scanner) addTokenString() error {
+	line := s.line
 	for {
 		c, err := s.peek()
 		if errors.Is(err, ErrEOF) {
-			s.er.ScanError(s.line, "unterminated string")
+			tok := s.errorToken()
+			tok.line = line
+			s.er.ScanError(tok, "unterminated string")
+			return nil
 		}
 		if c == '\n' {
 			s.line++
@@ -225,7 +249,7 @@ func (s *Scanner) addTokenNumber() error {
 		num, err = strconv.Atoi(lex)
 	}
 	if err != nil {
-		s.er.ScanError(s.line, fmt.Sprintf("invalid number '%s'", lex))
+		s.er.ScanError(s.errorToken(), fmt.Sprintf("invalid number '%s'", lex))
 		return nil
 	}
 	s.addToken(NUMBER, num)
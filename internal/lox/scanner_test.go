@@ -33,6 +33,26 @@ func Test_scanToken(t *testing.T) {
 			input: []byte("!="),
 			want:  []token{newToken(BANG_EQUAL, "!=", "!=", 1, 0)},
 		},
+		{
+			desc:  "Two_Char__QUESTION_DOT",
+			input: []byte("?."),
+			want:  []token{newToken(QUESTION_DOT, "?.", "?.", 1, 0)},
+		},
+		{
+			desc:  "Two_Char__EQUAL_GREATER",
+			input: []byte("=>"),
+			want:  []token{newToken(EQUAL_GREATER, "=>", "=>", 1, 0)},
+		},
+		{
+			desc:  "Two_Char__PIPE_GREATER",
+			input: []byte("|>"),
+			want:  []token{newToken(PIPE_GREATER, "|>", "|>", 1, 0)},
+		},
+		{
+			desc:  "Two_Char__QUESTION_QUESTION",
+			input: []byte("??"),
+			want:  []token{newToken(QUESTION_QUESTION, "??", "??", 1, 0)},
+		},
 		{
 			desc:  "Comment",
 			input: []byte("// This is some comment text"),
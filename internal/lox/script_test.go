@@ -0,0 +1,42 @@
+package lox
+
+import "testing"
+
+// runScript runs code on a new interpreter, then evaluates the expression
+// input and returns its value and the error it raised. code must scan, parse,
+// resolve and run without errors.
+func runScript(t *testing.T, code, input string) (any, error) {
+	t.Helper()
+	interpreter := NewInterpreter(nil)
+	resolver := NewResolver(nil, interpreter)
+
+	tokens, err := NewScanner(nil, []byte(code)).ScanTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmts, err := NewParser(nil, tokens).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resolver.Resolve(stmts); err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range stmts {
+		if err := interpreter.execute(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tokens, err = NewScanner(nil, []byte(input)).ScanTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expr, err := NewParser(nil, tokens).expression()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolver.resolveExpr(expr); err != nil {
+		t.Fatal(err)
+	}
+	return interpreter.evaluate(expr)
+}
@@ -15,10 +15,12 @@ type stmtVisitor interface {
 	visitVarStmt(e varStmt) error
 	visitWhileStmt(e whileStmt) error
 	visitForStmt(e forStmt) error
+	visitForInStmt(e forInStmt) error
 	visitBreakStmt(e breakStmt) error
 	visitContinueStmt(e continueStmt) error
 	visitBlockStmt(e blockStmt) error
 	visitClassStmt(e classStmt) error
+	visitYieldStmt(e yieldStmt) error
 }
 
 type exprStmt struct {
@@ -94,6 +96,18 @@ func (e forStmt) accept(v stmtVisitor) error {
 	return v.visitForStmt(e)
 }
 
+type forInStmt struct {
+	keyword  token
+	name     token
+	iterable expr
+	body     stmt
+	label    token
+}
+
+func (e forInStmt) accept(v stmtVisitor) error {
+	return v.visitForInStmt(e)
+}
+
 type breakStmt struct {
 	keyword token
 	label   token
@@ -129,3 +143,12 @@ type classStmt struct {
 func (e classStmt) accept(v stmtVisitor) error {
 	return v.visitClassStmt(e)
 }
+
+type yieldStmt struct {
+	keyword token
+	value   expr
+}
+
+func (e yieldStmt) accept(v stmtVisitor) error {
+	return v.visitYieldStmt(e)
+}
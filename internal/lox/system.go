@@ -0,0 +1,48 @@
+package lox
+
+import (
+	"fmt"
+	"os"
+)
+
+func defineSystemFns(env *environment) {
+	// args holds the command-line arguments passed to the script after its
+	// name. It is replaced by the Runtime.
+	env.define("args", newArray())
+
+	env.define("env", newBuiltinFn("env", 1, func(i *Interpreter, args []any) (any, error) {
+		name, err := stringArg("env", "Variable name", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, nil
+		}
+		return val, nil
+	}))
+
+	env.define("setenv", newBuiltinFn("setenv", 2, func(i *Interpreter, args []any) (any, error) {
+		name, err := stringArg("setenv", "Variable name", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		val, err := stringArg("setenv", "Value", args, 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Setenv(name, val); err != nil {
+			return nil, builtinErrMsg(fmt.Sprintf("Can't set environment variable '%s': %v.", name, err))
+		}
+		return nil, nil
+	}))
+
+	// exit stops the script. The Runtime exits with the given code as status.
+	env.define("exit", newBuiltinFn("exit", 1, func(i *Interpreter, args []any) (any, error) {
+		code, ok := args[0].(int)
+		if !ok {
+			return nil, builtinErrMsg("Exit code must be an integer.")
+		}
+		return nil, &scriptExit{code: code}
+	}))
+}
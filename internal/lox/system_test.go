@@ -0,0 +1,88 @@
+package lox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_system(t *testing.T) {
+	t.Setenv("GOLOX_TEST_VAR", "value")
+
+	testCases := []struct {
+		desc     string
+		args     []string
+		code     string
+		wantEnv  map[string]any
+		wantExit any
+	}{
+		{
+			desc:    "args",
+			args:    []string{"one", "two"},
+			code:    `var joined = join(args, ",");`,
+			wantEnv: map[string]any{"joined": "one,two"},
+		},
+		{
+			desc:    "no_args",
+			code:    `var count = len(args);`,
+			wantEnv: map[string]any{"count": 0},
+		},
+		{
+			desc: "env",
+			code: `var set = env("GOLOX_TEST_VAR");
+			var unset = env("GOLOX_TEST_UNSET");`,
+			wantEnv: map[string]any{"set": "value", "unset": nil},
+		},
+		{
+			desc: "setenv",
+			code: `setenv("GOLOX_TEST_VAR", "changed");
+			var val = env("GOLOX_TEST_VAR");`,
+			wantEnv: map[string]any{"val": "changed"},
+		},
+		{
+			desc: "exit_stops_script",
+			code: `var reached = false;
+			exit(3);
+			reached = true;`,
+			wantEnv:  map[string]any{"reached": false},
+			wantExit: 3,
+		},
+		{
+			desc: "exit_from_callback",
+			code: `fn run() {
+				each([1, 2], fn(x) { exit(x + 10); });
+			}
+			run();`,
+			wantExit: 11,
+		},
+		{
+			desc:     "exit_from_timer",
+			code:     `setTimeout(fn() { exit(4); }, 10);`,
+			wantExit: 4,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			er := NewLoxErrorReporter()
+			interpreter := NewInterpreter(er)
+			resolver := NewResolver(er, interpreter)
+			rt := NewRuntime(er, interpreter, resolver, WithArgs(tC.args), WithClock(&fakeClock{}))
+
+			rt.run([]byte(tC.code))
+			assert.False(t, er.HadError())
+			assert.False(t, er.HadRuntimeError())
+
+			for k, v := range tC.wantEnv {
+				val, exists := interpreter.globals.values[k]
+				assert.True(t, exists)
+				assert.Equal(t, v, val)
+			}
+			if tC.wantExit == nil {
+				assert.Nil(t, rt.exit)
+			} else if assert.NotNil(t, rt.exit) {
+				assert.Equal(t, tC.wantExit, rt.exit.code)
+			}
+		})
+	}
+}
@@ -28,14 +28,18 @@ const (
 
 	// One or two character tokens.
 
-	BANG          tokenType = "!"
-	BANG_EQUAL    tokenType = "!="
-	EQUAL         tokenType = "="
-	EQUAL_EQUAL   tokenType = "=="
-	GREATER       tokenType = ">"
-	GREATER_EQUAL tokenType = ">="
-	LESS          tokenType = "<"
-	LESS_EQUAL    tokenType = "<="
+	BANG              tokenType = "!"
+	BANG_EQUAL        tokenType = "!="
+	EQUAL             tokenType = "="
+	EQUAL_EQUAL       tokenType = "=="
+	EQUAL_GREATER     tokenType = "=>"
+	GREATER           tokenType = ">"
+	GREATER_EQUAL     tokenType = ">="
+	LESS              tokenType = "<"
+	LESS_EQUAL        tokenType = "<="
+	PIPE_GREATER      tokenType = "|>"
+	QUESTION_DOT      tokenType = "?."
+	QUESTION_QUESTION tokenType = "??"
 
 	// Literals.
 
@@ -46,6 +50,8 @@ const (
 	// Keywords.
 
 	AND      tokenType = "and"
+	ASYNC    tokenType = "async"
+	AWAIT    tokenType = "await"
 	CLASS    tokenType = "class"
 	ELSE     tokenType = "else"
 	FALSE    tokenType = "false"
@@ -63,6 +69,9 @@ const (
 	WHILE    tokenType = "while"
 	BREAK    tokenType = "break"
 	CONTINUE tokenType = "continue"
+	IN       tokenType = "in"
+	SPAWN    tokenType = "spawn"
+	YIELD    tokenType = "yield"
 
 	EOF tokenType = "EOF"
 )
@@ -116,6 +125,8 @@ func (t token) hasType(expected ...tokenType) bool {
 func lookupIdentifier(lex string) tokenType {
 	keywords := map[string]tokenType{
 		"and":      AND,
+		"async":    ASYNC,
+		"await":    AWAIT,
 		"class":    CLASS,
 		"else":     ELSE,
 		"false":    FALSE,
@@ -133,6 +144,9 @@ func lookupIdentifier(lex string) tokenType {
 		"while":    WHILE,
 		"break":    BREAK,
 		"continue": CONTINUE,
+		"in":       IN,
+		"spawn":    SPAWN,
+		"yield":    YIELD,
 	}
 	tt, ok := keywords[lex]
 	if !ok {
@@ -0,0 +1,100 @@
+package lox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Frame is a call in the traceback of a RuntimeError.
+type Frame struct {
+	// Function is the name of the function, as 'Class.method' for methods and
+	// '<script>' for the top level of the script.
+	Function string
+	// File is the script that defines the function, if it was run from a file.
+	File string
+	// Line is the line that was running in the function.
+	Line int
+}
+
+func (f Frame) String() string {
+	if f.File == "" {
+		return fmt.Sprintf("%s (line %d)", f.Function, f.Line)
+	}
+	return fmt.Sprintf("%s (%s:%d)", f.Function, f.File, f.Line)
+}
+
+// callFrame is a call of a Lox function in progress on an interpreter.
+type callFrame struct {
+	fn *function
+	// site is the token where fn was called, or the zero token if it was
+	// called from Go or on another goroutine.
+	site token
+}
+
+func (i *Interpreter) pushFrame(fn *function) {
+	i.frames = append(i.frames, callFrame{fn: fn, site: i.site})
+}
+
+func (i *Interpreter) popFrame() {
+	i.frames = i.frames[:len(i.frames)-1]
+}
+
+// traced adds the traceback of the calls in progress to err if it is a
+// RuntimeError that doesn't have one yet, which is the case when it leaves the
+// function where it was raised.
+func (i *Interpreter) traced(err error) error {
+	rtErr, ok := err.(RuntimeError)
+	if !ok || rtErr.Trace != nil {
+		return err
+	}
+	line := rtErr.Token.line
+	trace := make([]Frame, 0, len(i.frames)+1)
+	for idx := len(i.frames) - 1; idx >= 0; idx-- {
+		frame := i.frames[idx]
+		trace = append(trace, Frame{Function: frame.fn.qualifiedName(), File: frame.fn.file, Line: line})
+		line = frame.site.line
+	}
+	if line > 0 {
+		trace = append(trace, Frame{Function: "<script>", File: i.file, Line: line})
+	}
+	rtErr.Trace = trace
+	return rtErr
+}
+
+// Deep tracebacks, such as those of a stack overflow, are shortened when they
+// are printed: identical frames in a row are shown maxRepeatedFrames times, and
+// only the innermost and outermost maxTracebackLines/2 lines are kept.
+const (
+	maxRepeatedFrames = 3
+	maxTracebackLines = 20
+)
+
+// Traceback formats the calls that led to the error, from the innermost, with
+// one line per call. It is empty if the error was raised at the top level of
+// the script.
+func (e RuntimeError) Traceback() string {
+	var lines []string
+	for idx := 0; idx < len(e.Trace); {
+		frame := e.Trace[idx]
+		n := 1
+		for idx+n < len(e.Trace) && e.Trace[idx+n] == frame {
+			n++
+		}
+		for range min(n, maxRepeatedFrames) {
+			lines = append(lines, fmt.Sprintf("  at %s", frame))
+		}
+		if n > maxRepeatedFrames {
+			lines = append(lines, fmt.Sprintf("  ... repeated %d more times", n-maxRepeatedFrames))
+		}
+		idx += n
+	}
+	if len(lines) > maxTracebackLines {
+		half := maxTracebackLines / 2
+		omitted := fmt.Sprintf("  ... %d more lines", len(lines)-2*half)
+		lines = append(append(lines[:half:half], omitted), lines[len(lines)-half:]...)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,142 @@
+package lox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_traceback(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		code       string
+		wantStderr string
+	}{
+		{
+			desc:       "top_level",
+			code:       `print 1 - "one";`,
+			wantStderr: "[line 1] Runtime Error at '-': Operands must be numbers.\n",
+		},
+		{
+			desc: "nested_calls",
+			code: `fn inner(x) {
+				return x - "one";
+			}
+			fn outer(x) {
+				return inner(x) + 1;
+			}
+			outer(1);`,
+			wantStderr: `[line 2] Runtime Error at '-': Operands must be numbers.
+  at inner (main.lox:2)
+  at outer (main.lox:5)
+  at <script> (main.lox:7)
+`,
+		},
+		{
+			desc: "method_and_callback",
+			code: `class Greeter {
+				greet(name) { return "Hello, " + name.first; }
+			}
+			var greeter = Greeter();
+			map(["ann"],
+				x => greeter.greet(x));`,
+			wantStderr: `[line 2] Runtime Error at 'first': Only instances have properties.
+  at Greeter.greet (main.lox:2)
+  at <anonymous fn> (main.lox:6)
+  at <script> (main.lox:6)
+`,
+		},
+		{
+			desc: "native_error",
+			code: `fn parse(s) { return parseInt(s); }
+			parse(1);`,
+			wantStderr: `[line 1] Runtime Error at ')': Value passed to 'parseInt' must be a string.
+  at parse (main.lox:1)
+  at <script> (main.lox:2)
+`,
+		},
+		{
+			desc: "generator_resumed_elsewhere",
+			code: `fn gen() {
+				yield 1;
+				yield nil + 1;
+			}
+			var g = gen();
+			g.next();
+			fn consume() { return g.next(); }
+			consume();`,
+			wantStderr: `[line 3] Runtime Error at '+': Operands must be either numbers or strings.
+  at gen (main.lox:3)
+  at consume (main.lox:7)
+  at <script> (main.lox:8)
+`,
+		},
+		{
+			desc: "generator_in_for_in",
+			code: `fn gen() { yield nil + 1; }
+			fn loop() {
+				for x in gen() {}
+			}
+			loop();`,
+			wantStderr: `[line 1] Runtime Error at '+': Operands must be either numbers or strings.
+  at gen (main.lox:1)
+  at loop (main.lox:3)
+  at <script> (main.lox:5)
+`,
+		},
+		{
+			desc: "timer_callback",
+			code: `fn tick() { return nil + 1; }
+			setTimeout(tick, 0);`,
+			wantStderr: `[line 1] Runtime Error at '+': Operands must be either numbers or strings.
+  at tick (main.lox:1)
+  at <script> (main.lox:2)
+`,
+		},
+		{
+			desc: "recursion",
+			code: `fn countdown(n) {
+				if n == 0 { return nil - 1; }
+				return countdown(n - 1);
+			}
+			countdown(5);`,
+			wantStderr: `[line 2] Runtime Error at '-': Operands must be numbers.
+  at countdown (main.lox:2)
+  at countdown (main.lox:3)
+  at countdown (main.lox:3)
+  at countdown (main.lox:3)
+  ... repeated 2 more times
+  at <script> (main.lox:5)
+`,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+			var stderr strings.Builder
+			er := NewLoxErrorReporter()
+			interpreter := NewInterpreter(er)
+			resolver := NewResolver(er, interpreter)
+			rt := NewRuntime(er, interpreter, resolver, WithStderr(&stderr))
+			interpreter.file = "main.lox"
+
+			rt.run([]byte(tC.code))
+			assert.Equal(t, tC.wantStderr, withoutSnippet(stderr.String()))
+		})
+	}
+}
+
+func Test_Traceback_long(t *testing.T) {
+	var trace []Frame
+	for n := range 30 {
+		trace = append(trace, Frame{Function: "f", Line: n + 1})
+	}
+	err := RuntimeError{Msg: "Stack overflow.", Trace: trace}
+	lines := strings.Split(strings.TrimSuffix(err.Traceback(), "\n"), "\n")
+	assert.Len(t, lines, 21)
+	assert.Equal(t, "  at f (line 1)", lines[0])
+	assert.Equal(t, "  ... 10 more lines", lines[10])
+	assert.Equal(t, "  at f (line 30)", lines[20])
+}
@@ -0,0 +1,254 @@
+package lox
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Kind is the type of a Lox value.
+type Kind int
+
+const (
+	NilKind Kind = iota
+	BoolKind
+	IntKind
+	FloatKind
+	StringKind
+	ArrayKind
+	MapKind
+	InstanceKind
+	FunctionKind
+	ClassKind
+	// OtherKind is the kind of other native values, such as modules, futures
+	// and channels.
+	OtherKind
+)
+
+func (k Kind) String() string {
+	switch k {
+	case NilKind:
+		return "nil"
+	case BoolKind:
+		return "bool"
+	case IntKind:
+		return "int"
+	case FloatKind:
+		return "float"
+	case StringKind:
+		return "string"
+	case ArrayKind:
+		return "array"
+	case MapKind:
+		return "map"
+	case InstanceKind:
+		return "instance"
+	case FunctionKind:
+		return "function"
+	case ClassKind:
+		return "class"
+	default:
+		return "other"
+	}
+}
+
+// Value is a Lox value held by Go code. The zero Value is nil.
+type Value struct {
+	v any
+}
+
+func Nil() Value {
+	return Value{}
+}
+
+func Bool(b bool) Value {
+	return Value{b}
+}
+
+func Int(n int) Value {
+	return Value{n}
+}
+
+func Float(f float64) Value {
+	return Value{f}
+}
+
+func String(s string) Value {
+	return Value{s}
+}
+
+// Array returns a new array of items.
+func Array(items ...Value) Value {
+	arr := newArray()
+	for _, item := range items {
+		arr.Append(item.v)
+	}
+	return Value{arr}
+}
+
+// Map returns a new map of entries, with its keys in sorted order.
+func Map(entries map[string]Value) Value {
+	m := newMap()
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		m.Set(key, entries[key].v)
+	}
+	return Value{m}
+}
+
+func (v Value) Kind() Kind {
+	switch v.v.(type) {
+	case nil:
+		return NilKind
+	case bool:
+		return BoolKind
+	case int:
+		return IntKind
+	case float64:
+		return FloatKind
+	case string:
+		return StringKind
+	case *array:
+		return ArrayKind
+	case *loxMap:
+		return MapKind
+	case *instance:
+		return InstanceKind
+	case *class:
+		return ClassKind
+	case callable:
+		return FunctionKind
+	default:
+		return OtherKind
+	}
+}
+
+func (v Value) IsNil() bool {
+	return v.v == nil
+}
+
+func (v Value) AsBool() (bool, bool) {
+	b, ok := v.v.(bool)
+	return b, ok
+}
+
+func (v Value) AsInt() (int, bool) {
+	n, ok := v.v.(int)
+	return n, ok
+}
+
+// AsFloat returns the value of a number, converting ints to floats.
+func (v Value) AsFloat() (float64, bool) {
+	switch n := v.v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func (v Value) AsString() (string, bool) {
+	s, ok := v.v.(string)
+	return s, ok
+}
+
+// Len returns the length of a string, array or map, and 0 for other values.
+func (v Value) Len() int {
+	switch val := v.v.(type) {
+	case string:
+		return len(val)
+	case *array:
+		return val.Len()
+	case *loxMap:
+		return val.Len()
+	}
+	return 0
+}
+
+// Index returns the item of an array at idx, and false if v is not an array or
+// idx is out of range.
+func (v Value) Index(idx int) (Value, bool) {
+	arr, ok := v.v.(*array)
+	if !ok {
+		return Value{}, false
+	}
+	items := arr.Items()
+	if idx < 0 || idx >= len(items) {
+		return Value{}, false
+	}
+	return Value{items[idx]}, true
+}
+
+// Items returns a copy of the items of an array, or nil if v is not an array.
+func (v Value) Items() []Value {
+	arr, ok := v.v.(*array)
+	if !ok {
+		return nil
+	}
+	items := arr.Items()
+	out := make([]Value, len(items))
+	for idx, item := range items {
+		out[idx] = Value{item}
+	}
+	return out
+}
+
+// Keys returns the keys of a map in order, or nil if v is not a map.
+func (v Value) Keys() []Value {
+	m, ok := v.v.(*loxMap)
+	if !ok {
+		return nil
+	}
+	keys := m.Keys()
+	out := make([]Value, len(keys))
+	for idx, key := range keys {
+		out[idx] = Value{key}
+	}
+	return out
+}
+
+// Lookup returns the value of a map for key, and false if there is none.
+func (v Value) Lookup(key Value) (Value, bool) {
+	m, ok := v.v.(*loxMap)
+	if !ok {
+		return Value{}, false
+	}
+	val, ok, err := m.Get(key.v)
+	if err != nil {
+		return Value{}, false
+	}
+	return Value{val}, ok
+}
+
+// Field returns a field of an instance, and false if there is none. Methods
+// are not fields.
+func (v Value) Field(name string) (Value, bool) {
+	inst, ok := v.v.(*instance)
+	if !ok {
+		return Value{}, false
+	}
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	val, ok := inst.fields[name]
+	return Value{val}, ok
+}
+
+// ClassName returns the name of a class, or of the class of an instance.
+func (v Value) ClassName() string {
+	switch val := v.v.(type) {
+	case *class:
+		return val.name
+	case *instance:
+		return val.class.name
+	}
+	return ""
+}
+
+// String formats the value as the print statement does.
+func (v Value) String() string {
+	return fmt.Sprint(v.v)
+}
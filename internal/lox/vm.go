@@ -0,0 +1,299 @@
+package lox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// errorCollector is an ErrorReporter that keeps the errors it is given instead
+// of printing them, so that a VM can return them.
+type errorCollector struct {
+	mu              sync.Mutex
+	errs            []error
+	hadError        bool
+	hadRuntimeError bool
+}
+
+func (c *errorCollector) add(err error, runtime bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errs = append(c.errs, err)
+	if runtime {
+		c.hadRuntimeError = true
+	} else {
+		c.hadError = true
+	}
+}
+
+func (c *errorCollector) report(tok token, where, msg string) {
+	c.add(syntaxError{tok: tok, where: where, msg: msg}, false)
+}
+
+// syntaxError is an error found before the script runs that is not a
+// ParseError, such as a scan error.
+type syntaxError struct {
+	tok   token
+	where string
+	msg   string
+}
+
+func (e syntaxError) Error() string {
+	return fmt.Sprintf("[line %d] Error%s: %s", e.tok.line, e.where, e.msg)
+}
+
+func (c *errorCollector) ScanError(tok token, msg string) {
+	c.report(tok, "", msg)
+}
+
+func (c *errorCollector) ParseError(token token, msg string) ParseError {
+	err := NewParseError(token, msg)
+	c.add(err, false)
+	return err
+}
+
+func (c *errorCollector) RuntimeError(err RuntimeError) {
+	c.add(err, true)
+}
+
+func (c *errorCollector) HadError() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hadError
+}
+
+func (c *errorCollector) HadRuntimeError() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hadRuntimeError
+}
+
+func (c *errorCollector) ResetError() {
+	c.reset()
+}
+
+func (c *errorCollector) ResetRuntimeError() {
+	c.reset()
+}
+
+func (c *errorCollector) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errs = nil
+	c.hadError = false
+	c.hadRuntimeError = false
+}
+
+// err returns the collected errors joined into one, or nil if there are none.
+func (c *errorCollector) err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return errors.Join(c.errs...)
+}
+
+// ExitError is returned by a VM when the script calls exit.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// VM runs Lox code on behalf of a Go program. Its globals are kept between
+// runs, so that values defined by one script can be used by the next. Errors
+// are returned instead of printed.
+//
+// A VM is safe for concurrent use, but runs one script at a time.
+type VM struct {
+	// running holds a value while a script or a Func call runs on the VM.
+	running chan struct{}
+	er      *errorCollector
+	i       *Interpreter
+	rt      *Runtime
+	// diag prints the errors returned by runs, with the source they are in,
+	// if a writer was given with WithStderr.
+	diag *LoxErrorReporter
+}
+
+// NewVM returns a VM configured with the same options as a Runtime.
+func NewVM(opts ...RuntimeOption) *VM {
+	er := &errorCollector{}
+	i := NewInterpreter(er)
+	r := NewResolver(er, i)
+	vm := &VM{running: make(chan struct{}, 1), er: er, i: i, rt: NewRuntime(er, i, r, opts...)}
+	if vm.rt.stderr != nil {
+		vm.diag = NewLoxErrorReporter()
+		vm.diag.SetOutput(vm.rt.stderr)
+	}
+	return vm
+}
+
+// Eval runs src, and returns the value of its last statement if it is an
+// expression statement. src may also be a single expression without a
+// trailing semicolon, such as 'x + 1'. Timers and async functions started by
+// src are run to completion before Eval returns.
+func (vm *VM) Eval(src string) (Value, error) {
+	return vm.EvalContext(context.Background(), src)
+}
+
+// EvalContext is like Eval, but stops src with an InterruptError once ctx is
+// done.
+func (vm *VM) EvalContext(ctx context.Context, src string) (Value, error) {
+	return vm.eval(ctx, "", src)
+}
+
+// eval runs src, which was read from file if it is not empty.
+func (vm *VM) eval(ctx context.Context, file, src string) (Value, error) {
+	if err := vm.lock(ctx); err != nil {
+		return Value{}, err
+	}
+	defer vm.unlock()
+	val, err := vm.run(ctx, file, src)
+	if err != nil {
+		// The timers left by a failed run are dropped, so that they don't run
+		// with the next one.
+		vm.rt.loop.clear()
+	}
+	if err != nil && vm.diag != nil {
+		vm.diag.setSource(file, []byte(src))
+		vm.printError(err)
+	}
+	return val, err
+}
+
+// lock waits until nothing runs on the VM, and returns an InterruptError if
+// ctx is done first.
+func (vm *VM) lock(ctx context.Context) error {
+	select {
+	case vm.running <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return &InterruptError{Err: context.Cause(ctx)}
+	}
+}
+
+func (vm *VM) unlock() {
+	<-vm.running
+}
+
+// printError prints err, which was returned by a run, as the golox command
+// would.
+func (vm *VM) printError(err error) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			vm.printError(err)
+		}
+		return
+	}
+	var exit *ExitError
+	var rtErr RuntimeError
+	var parseErr ParseError
+	var synErr syntaxError
+	switch {
+	case errors.As(err, &exit):
+		// Exiting is not an error of the script.
+	case errors.As(err, &rtErr):
+		vm.diag.RuntimeError(rtErr)
+	case errors.As(err, &parseErr):
+		vm.diag.ParseError(parseErr.Token, parseErr.Msg)
+	case errors.As(err, &synErr):
+		vm.diag.report(synErr.tok, synErr.where, synErr.msg)
+	default:
+		fmt.Fprintln(vm.rt.stderr, err)
+	}
+}
+
+func (vm *VM) run(ctx context.Context, file, src string) (Value, error) {
+	vm.er.reset()
+	vm.i.start(ctx)
+	vm.i.file = file
+
+	stmts, err := vm.parse([]byte(src))
+	if err != nil {
+		return Value{}, err
+	}
+	var last any
+	for idx, s := range stmts {
+		if e, ok := s.(exprStmt); ok && idx == len(stmts)-1 {
+			last, err = vm.i.evaluate(e.expr)
+		} else {
+			err = vm.i.execute(s)
+		}
+		if err != nil {
+			return Value{}, vm.runError(err)
+		}
+	}
+	if err := vm.rt.runEventLoop(); err != nil {
+		return Value{}, vm.runError(err)
+	}
+	if err := vm.er.err(); err != nil {
+		return Value{}, err
+	}
+	return Value{last}, nil
+}
+
+// RunFile runs the script at path.
+func (vm *VM) RunFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("can't read file '%s': %w", path, err)
+	}
+	_, err = vm.eval(context.Background(), path, string(b))
+	return err
+}
+
+// GetGlobal returns the value of a global variable, and false if it is not
+// defined.
+func (vm *VM) GetGlobal(name string) (Value, bool) {
+	vm.i.globals.mu.RLock()
+	defer vm.i.globals.mu.RUnlock()
+	val, ok := vm.i.globals.values[name]
+	return Value{val}, ok
+}
+
+// SetGlobal defines or replaces a global variable.
+func (vm *VM) SetGlobal(name string, val Value) {
+	vm.i.globals.define(name, val.v)
+}
+
+// parse scans, parses and resolves src. If it is not a valid program but is a
+// valid expression, it is parsed as an expression statement.
+func (vm *VM) parse(src []byte) ([]stmt, error) {
+	tokens, err := NewScanner(vm.er, src).ScanTokens()
+	if err == nil {
+		err = vm.er.err()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stmts, _ := NewParser(vm.er, tokens).Parse()
+	if err := vm.er.err(); err != nil {
+		p := NewParser(&errorCollector{}, tokens)
+		e, exprErr := p.expression()
+		if exprErr != nil || !p.isAtEnd() {
+			return nil, err
+		}
+		vm.er.reset()
+		stmts = []stmt{exprStmt{expr: e}}
+	}
+
+	vm.rt.r.Resolve(stmts)
+	if err := vm.er.err(); err != nil {
+		return nil, err
+	}
+	return stmts, nil
+}
+
+// runError turns an error returned while running a script into the error
+// returned by the VM.
+func (vm *VM) runError(err error) error {
+	var exit *scriptExit
+	if errors.As(err, &exit) {
+		return &ExitError{Code: exit.code}
+	}
+	return err
+}
@@ -0,0 +1,204 @@
+// Package lox embeds the Lox interpreter in Go programs.
+//
+// A VM runs scripts and keeps their globals, which Go code can read and set as
+// Values:
+//
+//	vm := lox.New()
+//	vm.SetGlobal("name", lox.String("world"))
+//	greeting, err := vm.Eval(`"Hello, " + name`)
+//
+// Go functions and structs can be exposed to scripts with Register, which
+// converts arguments and results with reflection:
+//
+//	vm.Register("repeat", strings.Repeat)
+//	vm.Register("config", &cfg)
+//	vm.Eval(`config.Name = repeat("a", 3);`)
+package lox
+
+import (
+	"io"
+
+	interp "github.com/quangd42/golox/internal/lox"
+)
+
+type (
+	// VM runs Lox code. See New.
+	VM = interp.VM
+	// Func is a handle to a Lox function, class or bound method, returned by
+	// (*VM).Func, (*VM).FuncOf and (*VM).Method, which Go code can call.
+	Func = interp.Func
+	// Value is a Lox value held by Go code. The zero Value is nil.
+	Value = interp.Value
+	// Kind is the type of a Value.
+	Kind = interp.Kind
+	// Option configures a VM.
+	Option = interp.RuntimeOption
+
+	// ExitError is returned when a script calls exit.
+	ExitError = interp.ExitError
+	// ParseError is returned for a script that is not valid Lox.
+	ParseError = interp.ParseError
+	// RuntimeError is returned for an error raised while running a script.
+	RuntimeError = interp.RuntimeError
+	// Frame is a call in the traceback of a RuntimeError.
+	Frame = interp.Frame
+	// InterruptError is returned when a script is stopped by the cancellation
+	// of its context or by running out of steps. See WithStepLimit.
+	InterruptError = interp.InterruptError
+
+	// Capability is a group of native functions and modules that give scripts
+	// access to the world outside the VM. See WithCapabilities.
+	Capability = interp.Capability
+
+	// Clock tells the time to scripts and schedules their timers.
+	Clock = interp.Clock
+	// FS is the filesystem used by the fs module.
+	FS = interp.FS
+	// File is a file opened for writing by an FS.
+	File = interp.File
+)
+
+const (
+	NilKind      = interp.NilKind
+	BoolKind     = interp.BoolKind
+	IntKind      = interp.IntKind
+	FloatKind    = interp.FloatKind
+	StringKind   = interp.StringKind
+	ArrayKind    = interp.ArrayKind
+	MapKind      = interp.MapKind
+	InstanceKind = interp.InstanceKind
+	FunctionKind = interp.FunctionKind
+	ClassKind    = interp.ClassKind
+	OtherKind    = interp.OtherKind
+)
+
+const (
+	CapClock       = interp.CapClock
+	CapTimers      = interp.CapTimers
+	CapConcurrency = interp.CapConcurrency
+	CapFS          = interp.CapFS
+	CapExec        = interp.CapExec
+	CapEnv         = interp.CapEnv
+	CapStdin       = interp.CapStdin
+	CapRandom      = interp.CapRandom
+)
+
+// ErrStepLimit is the cause of the InterruptError returned when a script runs
+// more steps than allowed by WithStepLimit.
+var ErrStepLimit = interp.ErrStepLimit
+
+// New returns a VM with its own globals. Errors are returned by its methods
+// instead of being printed.
+func New(opts ...Option) *VM {
+	return interp.NewVM(opts...)
+}
+
+// WithClock sets the clock used for timers, clock, nanotime and the datetime
+// module.
+func WithClock(c Clock) Option {
+	return interp.WithClock(c)
+}
+
+// WithFS sets the filesystem used by the fs module, which is the filesystem of
+// the OS by default.
+func WithFS(fsys FS) Option {
+	return interp.WithFS(fsys)
+}
+
+// WithStdin sets the reader that scripts read their input from, which is
+// os.Stdin by default.
+func WithStdin(r io.Reader) Option {
+	return interp.WithStdin(r)
+}
+
+// WithStdout sets the writer that scripts print to, which is os.Stdout by
+// default.
+func WithStdout(w io.Writer) Option {
+	return interp.WithStdout(w)
+}
+
+// WithStderr sets a writer that the errors returned by the VM are also printed
+// to, as by the golox command: with the source line they are on and their
+// traceback. By default, errors are only returned.
+func WithStderr(w io.Writer) Option {
+	return interp.WithStderr(w)
+}
+
+// WithCapabilities gives scripts only the listed capabilities, instead of all
+// of them. The natives of the others raise a permission error when used.
+func WithCapabilities(caps ...Capability) Option {
+	return interp.WithCapabilities(caps...)
+}
+
+// WithStepLimit sets the number of steps each call to Eval may take, where a
+// step is a loop iteration or a function call. Scripts that take more are
+// stopped with an InterruptError wrapping ErrStepLimit.
+func WithStepLimit(steps int64) Option {
+	return interp.WithStepLimit(steps)
+}
+
+// WithMemoryLimit sets the approximate number of bytes that each call to Eval
+// may allocate for strings, arrays, maps and instances. Allocations past the
+// limit raise a "Memory limit exceeded." runtime error.
+func WithMemoryLimit(bytes int64) Option {
+	return interp.WithMemoryLimit(bytes)
+}
+
+// WithMaxDepth sets the maximum depth of nested calls, which is 10000 by
+// default. Deeper calls raise a "Stack overflow." runtime error.
+func WithMaxDepth(depth int) Option {
+	return interp.WithMaxDepth(depth)
+}
+
+// WithArgs sets the command-line arguments exposed to scripts as 'args'.
+func WithArgs(args []string) Option {
+	return interp.WithArgs(args)
+}
+
+// WithSeed seeds the random module, so that runs of a script are reproducible.
+func WithSeed(seed int64) Option {
+	return interp.WithSeed(seed)
+}
+
+// WithExec sets whether scripts may run commands with exec and startProcess,
+// which they may by default. It adds or removes CapExec from the capabilities
+// given to scripts.
+func WithExec(allow bool) Option {
+	return interp.WithExec(allow)
+}
+
+func Nil() Value {
+	return interp.Nil()
+}
+
+func Bool(b bool) Value {
+	return interp.Bool(b)
+}
+
+func Int(n int) Value {
+	return interp.Int(n)
+}
+
+func Float(f float64) Value {
+	return interp.Float(f)
+}
+
+func String(s string) Value {
+	return interp.String(s)
+}
+
+// ValueOf converts a Go value to a Lox value, in the same way as
+// (*VM).Register.
+func ValueOf(x any) (Value, error) {
+	return interp.ValueOf(x)
+}
+
+// Array returns a new array of items.
+func Array(items ...Value) Value {
+	return interp.Array(items...)
+}
+
+// Map returns a new map of entries, with its keys in sorted order.
+func Map(entries map[string]Value) Value {
+	return interp.Map(entries)
+}
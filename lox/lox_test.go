@@ -0,0 +1,794 @@
+package lox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_VM_Eval(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		src      string
+		wantKind Kind
+		want     string
+		wantErr  bool
+	}{
+		{
+			desc:     "expression_without_semicolon",
+			src:      `1 + 2`,
+			wantKind: IntKind,
+			want:     "3",
+		},
+		{
+			desc: "last_expression_statement",
+			src: `var x = 1.5;
+			x * 2;`,
+			wantKind: FloatKind,
+			want:     "3",
+		},
+		{
+			desc:     "last_statement_not_expression",
+			src:      `var x = 1;`,
+			wantKind: NilKind,
+			want:     "<nil>",
+		},
+		{
+			desc: "instance",
+			src: `class Point {
+				init(x, y) {
+					this.x = x;
+					this.y = y;
+				}
+			}
+			Point(1, 2);`,
+			wantKind: InstanceKind,
+			want:     "Point instance",
+		},
+		{
+			desc:     "function",
+			src:      `fn add(a, b) { return a + b; } add;`,
+			wantKind: FunctionKind,
+			want:     "<fn add>",
+		},
+		{
+			desc:     "value_is_taken_before_timers_run",
+			src:      `var done = false; setTimeout(fn() { done = true; }, 1); done;`,
+			wantKind: BoolKind,
+			want:     "false",
+		},
+		{
+			desc:    "syntax_error",
+			src:     `var = 1;`,
+			wantErr: true,
+		},
+		{
+			desc:    "resolve_error",
+			src:     `return 1;`,
+			wantErr: true,
+		},
+		{
+			desc:    "runtime_error",
+			src:     `1 - "one"`,
+			wantErr: true,
+		},
+		{
+			desc:    "runtime_error_in_timer",
+			src:     `setTimeout(fn() { nil(); }, 1);`,
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+			vm := New()
+			got, err := vm.Eval(tC.src)
+			if tC.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tC.wantKind, got.Kind())
+			assert.Equal(t, tC.want, got.String())
+		})
+	}
+}
+
+func Test_VM_errors(t *testing.T) {
+	vm := New()
+
+	_, err := vm.Eval(`var x = ;`)
+	var parseErr ParseError
+	assert.ErrorAs(t, err, &parseErr)
+
+	_, err = vm.Eval(`nil.field;`)
+	var rtErr RuntimeError
+	assert.ErrorAs(t, err, &rtErr)
+
+	_, err = vm.Eval(`exit(3);`)
+	var exitErr *ExitError
+	if assert.ErrorAs(t, err, &exitErr) {
+		assert.Equal(t, 3, exitErr.Code)
+	}
+
+	// Errors don't stick to the VM.
+	got, err := vm.Eval(`"still running"`)
+	assert.NoError(t, err)
+	assert.Equal(t, "still running", got.String())
+}
+
+func Test_VM_globals(t *testing.T) {
+	vm := New()
+	vm.SetGlobal("name", String("world"))
+	vm.SetGlobal("nums", Array(Int(1), Int(2), Float(3.5)))
+	vm.SetGlobal("config", Map(map[string]Value{"debug": Bool(true), "level": Nil()}))
+
+	_, err := vm.Eval(`
+	var greeting = "Hello, " + name;
+	var total = reduce(nums, fn(acc, n) { return acc + n; }, 0);
+	var debug = config["debug"];
+	class Pet {}
+	var pet = Pet();
+	pet.name = "Rex";`)
+	assert.NoError(t, err)
+
+	greeting, ok := vm.GetGlobal("greeting")
+	assert.True(t, ok)
+	s, ok := greeting.AsString()
+	assert.True(t, ok)
+	assert.Equal(t, "Hello, world", s)
+
+	total, _ := vm.GetGlobal("total")
+	f, ok := total.AsFloat()
+	assert.True(t, ok)
+	assert.Equal(t, 6.5, f)
+	_, ok = total.AsInt()
+	assert.False(t, ok)
+
+	debug, _ := vm.GetGlobal("debug")
+	b, ok := debug.AsBool()
+	assert.True(t, ok)
+	assert.True(t, b)
+
+	config, _ := vm.GetGlobal("config")
+	assert.Equal(t, []Value{String("debug"), String("level")}, config.Keys())
+	level, ok := config.Lookup(String("level"))
+	assert.True(t, ok)
+	assert.True(t, level.IsNil())
+
+	nums, _ := vm.GetGlobal("nums")
+	assert.Equal(t, 3, nums.Len())
+	second, ok := nums.Index(1)
+	assert.True(t, ok)
+	assert.Equal(t, Int(2), second)
+	_, ok = nums.Index(3)
+	assert.False(t, ok)
+
+	pet, _ := vm.GetGlobal("pet")
+	assert.Equal(t, "Pet", pet.ClassName())
+	petName, ok := pet.Field("name")
+	assert.True(t, ok)
+	assert.Equal(t, String("Rex"), petName)
+
+	_, ok = vm.GetGlobal("undefined")
+	assert.False(t, ok)
+}
+
+func Test_VM_RunFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.lox")
+	err := os.WriteFile(path, []byte(`var answer = 6 * 7;`), 0o644)
+	assert.NoError(t, err)
+
+	vm := New()
+	assert.NoError(t, vm.RunFile(path))
+	answer, _ := vm.GetGlobal("answer")
+	assert.Equal(t, Int(42), answer)
+
+	err = vm.RunFile(filepath.Join(t.TempDir(), "missing.lox"))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func Test_VM_streams(t *testing.T) {
+	var stdout strings.Builder
+	vm := New(WithStdout(&stdout), WithStdin(strings.NewReader("world\n")))
+	_, err := vm.Eval(`print "Hello, " + readLine(); println(1, 2);`)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello, world\n1 2\n", stdout.String())
+
+	var stderr strings.Builder
+	vm = New(WithStderr(&stderr))
+	_, err = vm.Eval("fn f() {\n  return 1 - \"one\";\n}\nf();")
+	assert.Error(t, err)
+	assert.Equal(t, `[line 2] Runtime Error at '-': Operands must be numbers.
+ --> 2:12
+  |
+2 |   return 1 - "one";
+  |            ^
+  at f (line 2)
+  at <script> (line 4)
+`, stderr.String())
+
+	// Expressions without a semicolon are not syntax errors.
+	stderr.Reset()
+	_, err = vm.Eval(`1 + 2`)
+	assert.NoError(t, err)
+	_, err = vm.Eval(`print 1 +;`)
+	assert.Error(t, err)
+	_, err = vm.Eval(`exit(3);`)
+	assert.Error(t, err)
+	assert.Equal(t, `[line 1] Error at ';': Expect an expression.
+ --> 1:10
+  |
+1 | print 1 +;
+  |          ^
+`, stderr.String())
+}
+
+func Test_VM_limits(t *testing.T) {
+	vm := New(WithStepLimit(1000))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := vm.EvalContext(ctx, `var ticks = 0; setTimeout(fn() { ticks = 1; }, 0); while true {}`)
+	var intr *InterruptError
+	assert.ErrorAs(t, err, &intr)
+	assert.ErrorIs(t, err, ErrStepLimit)
+
+	// The VM can still be used, and the timer of the interrupted script is not
+	// run.
+	_, err = vm.Eval(`nil;`)
+	assert.NoError(t, err)
+	got, _ := vm.GetGlobal("ticks")
+	assert.Equal(t, Int(0), got)
+
+	vm = New()
+	_, err = vm.EvalContext(ctx, `while true {}`)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = vm.Eval(`fn spin() { while true {} }`)
+	assert.NoError(t, err)
+	spin, _ := vm.Func("spin")
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = spin.CallContext(canceled)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_VM_maxDepthThroughGo(t *testing.T) {
+	vm := New(WithMaxDepth(50))
+	deepest := 0
+	vm.RegisterFunc("viaGo", func(args ...Value) (Value, error) {
+		f, err := vm.FuncOf(args[0])
+		if err != nil {
+			return Value{}, err
+		}
+		n, _ := args[1].AsInt()
+		deepest = max(deepest, n)
+		return f.Call(args[0], Int(n+1))
+	})
+
+	// Each call through Go is nested in the calls of the script.
+	_, err := vm.Eval(`fn f(self, n) { return viaGo(self, n); } f(f, 0);`)
+	var rtErr RuntimeError
+	if assert.ErrorAs(t, err, &rtErr) {
+		assert.Equal(t, "Stack overflow.", rtErr.Msg)
+	}
+	assert.Less(t, deepest, 50)
+}
+
+func Test_VM_failedRunDropsTimers(t *testing.T) {
+	eval := func(code string) func(vm *VM) error {
+		return func(vm *VM) error {
+			_, err := vm.Eval(`setTimeout(tick, 0); ` + code)
+			return err
+		}
+	}
+	testCases := []struct {
+		desc string
+		run  func(vm *VM) error
+	}{
+		{desc: "runtime_error", run: eval(`nil + 1;`)},
+		{desc: "go_error", run: eval(`fail();`)},
+		{desc: "exit", run: eval(`exit(1);`)},
+		{
+			desc: "func_call",
+			run: func(vm *VM) error {
+				broken, _ := vm.Func("broken")
+				_, err := broken.Call()
+				return err
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			vm := New()
+			vm.RegisterFunc("fail", func(args ...Value) (Value, error) {
+				return Value{}, errors.New("failed")
+			})
+			_, err := vm.Eval(`var ticks = 0;
+			fn tick() { ticks = ticks + 1; }
+			fn broken() { setTimeout(tick, 0); return nil + 1; }`)
+			assert.NoError(t, err)
+			assert.Error(t, tC.run(vm))
+
+			// The timer of the failed run doesn't run with the next one.
+			_, err = vm.Eval(`nil;`)
+			assert.NoError(t, err)
+			got, _ := vm.GetGlobal("ticks")
+			assert.Equal(t, Int(0), got)
+		})
+	}
+}
+
+func Test_VM_capabilities(t *testing.T) {
+	vm := New(WithCapabilities(CapClock))
+	got, err := vm.Eval(`clock() > 0`)
+	assert.NoError(t, err)
+	assert.Equal(t, Bool(true), got)
+
+	_, err = vm.Eval(`fs.readFile("secret.txt");`)
+	var rtErr RuntimeError
+	if assert.ErrorAs(t, err, &rtErr) {
+		assert.Equal(t, "Permission denied: 'fs' is disabled.", rtErr.Msg)
+	}
+}
+
+func Test_RuntimeError_Trace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.lox")
+	err := os.WriteFile(path, []byte(`class Cart {
+	total() {
+		return reduce(this.items, (sum, item) => sum + item.price, 0);
+	}
+}
+var cart = Cart();
+cart.items = [{"price": 1}, {"price": "free"}];
+print cart.total();`), 0o644)
+	assert.NoError(t, err)
+
+	vm := New()
+	err = vm.RunFile(path)
+	var rtErr RuntimeError
+	if assert.ErrorAs(t, err, &rtErr) {
+		assert.Equal(t, []Frame{
+			{Function: "<anonymous fn>", File: path, Line: 3},
+			{Function: "Cart.total", File: path, Line: 3},
+			{Function: "<script>", File: path, Line: 8},
+		}, rtErr.Trace)
+	}
+}
+
+type account struct {
+	Owner   string
+	Balance float64 `lox:"balance"`
+	Tags    []string
+	secret  string
+	Hidden  string `lox:"-"`
+}
+
+func (a *account) Deposit(amount float64) error {
+	if amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	a.Balance += amount
+	return nil
+}
+
+func (a account) Summary() string {
+	return a.Owner + ": " + strconv.FormatFloat(a.Balance, 'f', 2, 64)
+}
+
+func Test_VM_RegisterFunc(t *testing.T) {
+	vm := New()
+	vm.RegisterFunc("count", func(args ...Value) (Value, error) {
+		return Int(len(args)), nil
+	})
+	vm.RegisterFunc("fail", func(args ...Value) (Value, error) {
+		return Value{}, errors.New("failed on purpose")
+	})
+
+	got, err := vm.Eval(`count() + count(1, "two", [3])`)
+	assert.NoError(t, err)
+	assert.Equal(t, Int(3), got)
+
+	_, err = vm.Eval(`fail()`)
+	assert.ErrorContains(t, err, "failed on purpose")
+}
+
+func Test_VM_Register(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		name    string
+		value   any
+		src     string
+		want    any
+		wantErr bool
+	}{
+		{
+			desc:  "func_with_conversions",
+			name:  "repeat",
+			value: strings.Repeat,
+			src:   `repeat("ab", 3)`,
+			want:  "ababab",
+		},
+		{
+			desc:  "func_with_slices_and_maps",
+			name:  "total",
+			value: func(nums []int, weights map[string]float64) float64 { return float64(len(nums)) * weights["w"] },
+			src:   `total([1, 2, 3], {"w": 1.5})`,
+			want:  4.5,
+		},
+		{
+			desc:  "func_returning_slice",
+			name:  "split",
+			value: strings.Fields,
+			src:   `len(split("a b c"))`,
+			want:  3,
+		},
+		{
+			desc:  "func_returning_map",
+			name:  "counts",
+			value: func() map[string]int { return map[string]int{"b": 2, "a": 1} },
+			src:   `join(keys(counts()), ",")`,
+			want:  "a,b",
+		},
+		{
+			desc: "variadic_func",
+			name: "sum",
+			value: func(base int, nums ...int) int {
+				s := base
+				for _, n := range nums {
+					s += n
+				}
+				return s
+			},
+			src:  `sum(1) + sum(1, 2, 3)`,
+			want: 7,
+		},
+		{
+			desc:  "func_taking_any",
+			name:  "describe",
+			value: func(v any) string { return fmt.Sprintf("%T", v) },
+			src:   `describe([1]) + describe({"a": 1}) + describe(1.5) + describe(nil)`,
+			want:  "[]interface {}map[string]interface {}float64<nil>",
+		},
+		{
+			desc:    "wrong_argument_type",
+			name:    "repeat",
+			value:   strings.Repeat,
+			src:     `repeat(1, 3)`,
+			wantErr: true,
+		},
+		{
+			desc:    "wrong_number_of_arguments",
+			name:    "repeat",
+			value:   strings.Repeat,
+			src:     `repeat("a")`,
+			wantErr: true,
+		},
+		{
+			desc:    "int_overflow",
+			name:    "small",
+			value:   func(n int8) int8 { return n },
+			src:     `small(300)`,
+			wantErr: true,
+		},
+		{
+			desc:    "panic_becomes_error",
+			name:    "boom",
+			value:   func() { panic("boom") },
+			src:     `boom()`,
+			wantErr: true,
+		},
+		{
+			desc:  "plain_value",
+			name:  "limits",
+			value: []float32{0.5, 2},
+			src:   `limits[0] + limits[1]`,
+			want:  2.5,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+			vm := New()
+			assert.NoError(t, vm.Register(tC.name, tC.value))
+			got, err := vm.Eval(tC.src)
+			if tC.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tC.want, got.Interface())
+		})
+	}
+}
+
+func Test_VM_Register_struct(t *testing.T) {
+	acc := &account{Owner: "ann", Balance: 10, secret: "s", Hidden: "h"}
+	vm := New()
+	assert.NoError(t, vm.Register("acc", acc))
+
+	_, err := vm.Eval(`
+	acc.Deposit(5);
+	acc.Owner = "Ann";
+	acc.Tags = ["vip"];
+	var summary = acc.Summary();
+	var balance = acc.balance;`)
+	assert.NoError(t, err)
+	assert.Equal(t, 15.0, acc.Balance)
+	assert.Equal(t, "Ann", acc.Owner)
+	assert.Equal(t, []string{"vip"}, acc.Tags)
+
+	summary, _ := vm.GetGlobal("summary")
+	assert.Equal(t, "Ann: 15.00", summary.Interface())
+
+	for _, src := range []string{
+		`acc.Deposit(-1);`,
+		`acc.secret;`,
+		`acc.Hidden;`,
+		`acc.Balance;`,
+		`acc.Owner = 1;`,
+		`acc.Missing = 1;`,
+	} {
+		_, err := vm.Eval(src)
+		assert.Error(t, err, src)
+	}
+
+	// Go objects can be passed back to Go functions.
+	assert.NoError(t, vm.Register("owner", func(a *account) string { return a.Owner }))
+	got, err := vm.Eval(`owner(acc)`)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ann", got.Interface())
+
+	got, err = vm.Eval(`acc`)
+	assert.NoError(t, err)
+	assert.Same(t, acc, got.Interface())
+}
+
+func Test_ValueOf(t *testing.T) {
+	_, err := ValueOf(make(chan int))
+	assert.Error(t, err)
+
+	v, err := ValueOf(uint64(math.MaxInt))
+	assert.NoError(t, err)
+	assert.Equal(t, Int(math.MaxInt), v)
+	_, err = ValueOf(uint64(math.MaxInt) + 1)
+	assert.EqualError(t, err, "9223372036854775808 overflows int")
+	_, err = ValueOf([]uint{math.MaxUint})
+	assert.Error(t, err)
+
+	v, err = ValueOf(map[string][]int{"a": {1, 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, MapKind, v.Kind())
+	assert.Equal(t, map[string]any{"a": []any{1, 2}}, v.Interface())
+}
+
+func Test_Func(t *testing.T) {
+	vm := New()
+	_, err := vm.Eval(`
+	var events = [];
+	fn onEvent(name, payload) {
+		append(events, name);
+		return len(events);
+	}
+	fn broken() {
+		return 1 - "one";
+	}
+	fn quit() {
+		exit(2);
+	}
+	fn later() {
+		setTimeout(fn() { append(events, "timer"); }, 1);
+	}
+	class Counter {
+		init(start) {
+			this.count = start;
+		}
+		add(n) {
+			this.count = this.count + n;
+			return this;
+		}
+	}
+	var counter = Counter(10);`)
+	assert.NoError(t, err)
+
+	onEvent, err := vm.Func("onEvent")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, onEvent.Arity())
+	got, err := onEvent.Call("click", map[string]int{"x": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, Int(1), got)
+	got, err = onEvent.Call("key", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Int(2), got)
+
+	_, err = onEvent.Call("too few")
+	assert.Error(t, err)
+
+	broken, _ := vm.Func("broken")
+	_, err = broken.Call()
+	var rtErr RuntimeError
+	if assert.ErrorAs(t, err, &rtErr) {
+		assert.Equal(t, 8, rtErr.Line())
+	}
+
+	quit, _ := vm.Func("quit")
+	_, err = quit.Call()
+	var exitErr *ExitError
+	if assert.ErrorAs(t, err, &exitErr) {
+		assert.Equal(t, 2, exitErr.Code)
+	}
+
+	later, _ := vm.Func("later")
+	_, err = later.Call()
+	assert.NoError(t, err)
+	events, _ := vm.GetGlobal("events")
+	assert.Equal(t, []any{"click", "key", "timer"}, events.Interface())
+
+	counterClass, err := vm.Func("Counter")
+	assert.NoError(t, err)
+	other, err := counterClass.Call(5)
+	assert.NoError(t, err)
+	assert.Equal(t, "Counter", other.ClassName())
+
+	counter, _ := vm.GetGlobal("counter")
+	add, err := vm.Method(counter, "add")
+	assert.NoError(t, err)
+	_, err = add.Call(5)
+	assert.NoError(t, err)
+	count, _ := counter.Field("count")
+	assert.Equal(t, Int(15), count)
+
+	_, err = vm.Method(counter, "missing")
+	assert.Error(t, err)
+	_, err = vm.Func("events")
+	assert.Error(t, err)
+	_, err = vm.Func("undefined")
+	assert.Error(t, err)
+}
+
+func Test_Func_fromGoCallback(t *testing.T) {
+	vm := New()
+	var handlers []*Func
+	assert.NoError(t, vm.Register("subscribe", func(handler Value) error {
+		fn, err := vm.FuncOf(handler)
+		if err != nil {
+			return err
+		}
+		handlers = append(handlers, fn)
+		return nil
+	}))
+	// Calls back into Lox while the script is running.
+	assert.NoError(t, vm.Register("emit", func(n int) (int, error) {
+		total := 0
+		for _, h := range handlers {
+			res, err := h.Call(n)
+			if err != nil {
+				return 0, err
+			}
+			v, _ := res.AsInt()
+			total += v
+		}
+		return total, nil
+	}))
+
+	got, err := vm.Eval(`
+	subscribe(x => x * 2);
+	subscribe(fn(x) { return x + 1; });
+	emit(10);`)
+	assert.NoError(t, err)
+	assert.Equal(t, Int(31), got)
+
+	// Handlers can also be called after the script has finished.
+	res, err := handlers[0].Call(4)
+	assert.NoError(t, err)
+	assert.Equal(t, Int(8), res)
+
+	// exit in a handler called from Go stops the script.
+	_, err = vm.Eval(`subscribe(fn(x) { exit(x); }); emit(7); print "unreachable";`)
+	var exitErr *ExitError
+	if assert.ErrorAs(t, err, &exitErr) {
+		assert.Equal(t, 7, exitErr.Code)
+	}
+}
+
+func Test_Func_concurrent(t *testing.T) {
+	vm := New()
+	_, err := vm.Eval(`
+	var count = 0;
+	fn bump() {
+		count = count + 1;
+		return count;
+	}`)
+	assert.NoError(t, err)
+	bump, err := vm.Func("bump")
+	assert.NoError(t, err)
+
+	// Calls made from another goroutine while a script runs wait for it.
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, err := vm.Eval(`for var n = 0; n < 500; n = n + 1 { bump(); }`)
+		assert.NoError(t, err)
+	}()
+	go func() {
+		defer wg.Done()
+		for range 500 {
+			_, err := bump.Call()
+			assert.NoError(t, err)
+		}
+	}()
+	wg.Wait()
+	count, _ := vm.GetGlobal("count")
+	assert.Equal(t, Int(1000), count)
+
+	// Their own context stops them while they wait.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	loopCtx, loopCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer loopCancel()
+	started := make(chan struct{})
+	assert.NoError(t, vm.Register("started", func() { close(started) }))
+	loopDone := make(chan error)
+	go func() {
+		_, err := vm.EvalContext(loopCtx, `started(); while true {}`)
+		loopDone <- err
+	}()
+	<-started
+	_, err = bump.CallContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, <-loopDone, context.DeadlineExceeded)
+	count, _ = vm.GetGlobal("count")
+	assert.Equal(t, Int(1000), count)
+}
+
+func Test_Func_whileGoFunctionBlocks(t *testing.T) {
+	vm := New()
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	assert.NoError(t, vm.Register("block", func() { close(entered); <-release }))
+	_, err := vm.Eval(`var count = 0; fn bump() { count = count + 1; return count; }`)
+	assert.NoError(t, err)
+	bump, err := vm.Func("bump")
+	assert.NoError(t, err)
+
+	evalDone := make(chan error)
+	go func() {
+		_, err := vm.Eval(`block(); count = count * 10;`)
+		evalDone <- err
+	}()
+	<-entered
+
+	// A call from another goroutine waits for the script, even though the
+	// script is inside a Go function, and gives up once its context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = bump.CallContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	callDone := make(chan Value)
+	go func() {
+		res, err := bump.Call()
+		assert.NoError(t, err)
+		callDone <- res
+	}()
+	select {
+	case <-callDone:
+		t.Fatal("Call returned while the script was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	assert.NoError(t, <-evalDone)
+	assert.Equal(t, Int(1), <-callDone)
+}
@@ -1,24 +1,61 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"os"
 
 	"github.com/quangd42/golox/internal/lox"
 )
 
 func main() {
-	if len(os.Args) > 2 {
-		println("Usage: golox [script]")
-		os.Exit(64)
+	seed := flag.Int64("seed", 0, "seed the random module, for reproducible runs")
+	errorFormat := flag.String("error-format", "text", "print errors as `text` or json")
+	color := flag.String("color", "auto", "color errors: `auto`, always or never")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [script [args...]]\n", os.Args[0])
+		flag.PrintDefaults()
 	}
+	flag.Parse()
 
 	er := lox.NewLoxErrorReporter()
+	switch lox.ErrorFormat(*errorFormat) {
+	case lox.TextFormat, lox.JSONFormat:
+		er.SetFormat(lox.ErrorFormat(*errorFormat))
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -error-format '%s': must be text or json\n", *errorFormat)
+		os.Exit(64)
+	}
+	switch *color {
+	case "always":
+		er.SetColor(true)
+	case "auto":
+		// Color errors only when they are read on a terminal.
+		fi, err := os.Stderr.Stat()
+		er.SetColor(err == nil && fi.Mode()&os.ModeCharDevice != 0 && os.Getenv("NO_COLOR") == "")
+	case "never":
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -color '%s': must be auto, always or never\n", *color)
+		os.Exit(64)
+	}
+
+	var opts []lox.RuntimeOption
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			opts = append(opts, lox.WithSeed(*seed))
+		}
+	})
+	if flag.NArg() > 1 {
+		// Arguments after the script name are passed to the script.
+		opts = append(opts, lox.WithArgs(flag.Args()[1:]))
+	}
+
 	i := lox.NewInterpreter(er)
 	r := lox.NewResolver(er, i)
-	runtime := lox.NewRuntime(er, i, r)
+	runtime := lox.NewRuntime(er, i, r, opts...)
 
-	if len(os.Args) == 2 {
-		runtime.RunFile(os.Args[1])
+	if flag.NArg() >= 1 {
+		runtime.RunFile(flag.Arg(0))
 	} else {
 		runtime.RunPrompt()
 	}
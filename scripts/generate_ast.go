@@ -11,17 +11,22 @@ import (
 )
 
 var ExprTypes = []string{
-	"Array: value []expr",
+	"Array: bracket token, value []expr",
 	"Assign: name token, value expr",
+	"Await: keyword token, value expr",
 	"Binary: left expr, operator token, right expr",
 	"Call: callee expr, paren token, arguments []expr",
-	"Function: params []token, body []stmt",
-	"Get: object expr, name token",
+	"Function: params []token, body []stmt, isGenerator bool, isAsync bool",
+	"Get: object expr, name token, optional bool",
 	"Grouping: expr expr",
-	"Index: callee expr, bracket token, index expr",
+	"Index: callee expr, bracket token, index expr, optional bool",
+	"IndexSet: callee expr, bracket token, index expr, value expr",
 	"Literal: value any",
 	"Logical: left expr, operator token, right expr",
+	"Map: brace token, keys []expr, values []expr",
+	"OptionalChain: chain expr",
 	"Set: object expr, name token, value expr",
+	"Spawn: keyword token, call callExpr",
 	"Super: keyword token, method token",
 	"Ternary: condition expr, thenExpr expr, elseExpr expr",
 	"This: keyword token",
@@ -38,10 +43,12 @@ var StmtTypes = []string{
 	"Var: name token, initializer expr",
 	"While: condition expr, body stmt, label token, increment stmt",
 	"For: initializer stmt, whileBody whileStmt",
+	"ForIn: keyword token, name token, iterable expr, body stmt, label token",
 	"Break: keyword token, label token",
 	"Continue: keyword token, label token",
 	"Block: statements []stmt",
 	"Class: name token, superclass variableExpr, methods []functionStmt",
+	"Yield: keyword token, value expr",
 }
 
 func main() {
@@ -80,6 +87,15 @@ func lower(s string) string {
 	return strings.ToLower(s)
 }
 
+// lowerFirst lowercases the first letter of a type name, keeping the rest of
+// its camel case.
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
 func defineBaseInterface(w io.Writer, baseName, returnStr string) {
 	baseName = lower(baseName)
 	fmt.Fprintf(w, "type %s interface {\n", baseName)
@@ -95,7 +111,7 @@ func defineVisitorInterface(w io.Writer, baseName, returnStr string, types []str
 		if !found {
 			log.Fatalf("invalid ast format %s\n", t)
 		}
-		fmt.Fprintf(w, "	visit%s%s(e %s%s) %s\n", name, baseName, lower(name), baseName, returnStr)
+		fmt.Fprintf(w, "	visit%s%s(e %s%s) %s\n", name, baseName, lowerFirst(name), baseName, returnStr)
 	}
 	fmt.Fprintln(w, "}")
 	fmt.Fprintln(w, "")
@@ -108,14 +124,14 @@ func defineTypes(w io.Writer, baseName, returnStr string, types []string) {
 			log.Fatalf("invalid ast format %s\n", t)
 		}
 		fields := strings.Split(fieldsStr, ", ")
-		fmt.Fprintf(w, "type %s%s struct {\n", lower(typeName), baseName)
+		fmt.Fprintf(w, "type %s%s struct {\n", lowerFirst(typeName), baseName)
 		for _, f := range fields {
 			fmt.Fprintf(w, "	%s\n", strings.TrimSpace(f))
 		}
 		fmt.Fprintln(w, "}")
 		fmt.Fprintln(w, "")
 
-		fmt.Fprintf(w, "func (e %s%s) accept(v %sVisitor) %s {\n", lower(typeName), baseName, lower(baseName), returnStr)
+		fmt.Fprintf(w, "func (e %s%s) accept(v %sVisitor) %s {\n", lowerFirst(typeName), baseName, lower(baseName), returnStr)
 		fmt.Fprintf(w, "	return v.visit%s%s(e)\n", typeName, baseName)
 		fmt.Fprintln(w, "}")
 		fmt.Fprintln(w, "")